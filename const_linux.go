@@ -53,33 +53,56 @@ const (
 
 // Socket options (SOL_SOCKET level).
 const (
-	SO_DEBUG        = 1
-	SO_REUSEADDR    = 2
-	SO_TYPE         = 3
-	SO_ERROR        = 4
-	SO_DONTROUTE    = 5
-	SO_BROADCAST    = 6
-	SO_SNDBUF       = 7
-	SO_RCVBUF       = 8
-	SO_KEEPALIVE    = 9
-	SO_OOBINLINE    = 10
-	SO_NO_CHECK     = 11
-	SO_PRIORITY     = 12
-	SO_LINGER       = 13
-	SO_BSDCOMPAT    = 14
-	SO_REUSEPORT    = 15
-	SO_RCVLOWAT     = 18
-	SO_SNDLOWAT     = 19
-	SO_RCVTIMEO     = 20
-	SO_SNDTIMEO     = 21
-	SO_ACCEPTCONN   = 30
-	SO_SNDBUFFORCE  = 32
-	SO_RCVBUFFORCE  = 33
-	SO_PROTOCOL     = 38
-	SO_DOMAIN       = 39
-	SO_ZEROCOPY     = 60
-	SO_INCOMING_CPU = 49
-	SO_BUSY_POLL    = 46
+	SO_DEBUG            = 1
+	SO_REUSEADDR        = 2
+	SO_TYPE             = 3
+	SO_ERROR            = 4
+	SO_DONTROUTE        = 5
+	SO_BROADCAST        = 6
+	SO_SNDBUF           = 7
+	SO_RCVBUF           = 8
+	SO_KEEPALIVE        = 9
+	SO_OOBINLINE        = 10
+	SO_NO_CHECK         = 11
+	SO_PRIORITY         = 12
+	SO_LINGER           = 13
+	SO_BSDCOMPAT        = 14
+	SO_REUSEPORT        = 15
+	SO_RCVLOWAT         = 18
+	SO_SNDLOWAT         = 19
+	SO_RCVTIMEO         = 20
+	SO_SNDTIMEO         = 21
+	SO_ACCEPTCONN       = 30
+	SO_SNDBUFFORCE      = 32
+	SO_RCVBUFFORCE      = 33
+	SO_PROTOCOL         = 38
+	SO_DOMAIN           = 39
+	SO_ZEROCOPY         = 60
+	SO_INCOMING_CPU     = 49
+	SO_BUSY_POLL        = 46
+	SO_INCOMING_NAPI_ID = 56
+	SO_TIMESTAMPING     = 37
+)
+
+// SO_TIMESTAMPING flags.
+const (
+	SOF_TIMESTAMPING_TX_HARDWARE  = 1 << 0
+	SOF_TIMESTAMPING_TX_SOFTWARE  = 1 << 1
+	SOF_TIMESTAMPING_RX_HARDWARE  = 1 << 2
+	SOF_TIMESTAMPING_RX_SOFTWARE  = 1 << 3
+	SOF_TIMESTAMPING_SOFTWARE     = 1 << 4
+	SOF_TIMESTAMPING_RAW_HARDWARE = 1 << 6
+	SOF_TIMESTAMPING_OPT_ID       = 1 << 7
+	SOF_TIMESTAMPING_TX_SCHED     = 1 << 8
+	SOF_TIMESTAMPING_TX_ACK       = 1 << 9
+	SOF_TIMESTAMPING_OPT_TSONLY   = 1 << 11
+)
+
+// Transmit timestamp types.
+const (
+	SCM_TSTAMP_SND   = 0
+	SCM_TSTAMP_SCHED = 1
+	SCM_TSTAMP_ACK   = 2
 )
 
 // TCP options.
@@ -180,6 +203,144 @@ const (
 	CLOCK_MONOTONIC = 1
 )
 
+// Special dirfd and flags for *at syscalls.
+const (
+	AT_FDCWD            = -100
+	AT_SYMLINK_NOFOLLOW = 0x100
+	AT_REMOVEDIR        = 0x200
+	AT_SYMLINK_FOLLOW   = 0x400
+	AT_EMPTY_PATH       = 0x1000
+)
+
+// openat2 resolve flags.
+const (
+	RESOLVE_NO_XDEV       = 0x01
+	RESOLVE_NO_MAGICLINKS = 0x02
+	RESOLVE_NO_SYMLINKS   = 0x04
+	RESOLVE_BENEATH       = 0x08
+	RESOLVE_IN_ROOT       = 0x10
+	RESOLVE_CACHED        = 0x20
+)
+
+// renameat2 flags.
+const (
+	RENAME_NOREPLACE = 1 << 0
+	RENAME_EXCHANGE  = 1 << 1
+	RENAME_WHITEOUT  = 1 << 2
+)
+
+// fallocate modes.
+const (
+	FALLOC_FL_KEEP_SIZE  = 0x01
+	FALLOC_FL_PUNCH_HOLE = 0x02
+	FALLOC_FL_ZERO_RANGE = 0x10
+)
+
+// sync_file_range flags.
+const (
+	SYNC_FILE_RANGE_WAIT_BEFORE = 0x1
+	SYNC_FILE_RANGE_WRITE       = 0x2
+	SYNC_FILE_RANGE_WAIT_AFTER  = 0x4
+)
+
+// fadvise advice values.
+const (
+	POSIX_FADV_NORMAL     = 0
+	POSIX_FADV_RANDOM     = 1
+	POSIX_FADV_SEQUENTIAL = 2
+	POSIX_FADV_WILLNEED   = 3
+	POSIX_FADV_DONTNEED   = 4
+	POSIX_FADV_NOREUSE    = 5
+)
+
+// madvise advice values.
+const (
+	MADV_NORMAL     = 0
+	MADV_RANDOM     = 1
+	MADV_SEQUENTIAL = 2
+	MADV_WILLNEED   = 3
+	MADV_DONTNEED   = 4
+)
+
+// xattr flags.
+const (
+	XATTR_CREATE  = 0x1
+	XATTR_REPLACE = 0x2
+)
+
+// waitid id types and options.
+const (
+	P_ALL   = 0
+	P_PID   = 1
+	P_PGID  = 2
+	P_PIDFD = 3
+
+	WNOHANG    = 0x1
+	WSTOPPED   = 0x2
+	WEXITED    = 0x4
+	WCONTINUED = 0x8
+	WNOWAIT    = 0x1000000
+)
+
+// epoll flags, control operations, and events.
+const (
+	EPOLL_CLOEXEC = 0x80000
+
+	EPOLL_CTL_ADD = 1
+	EPOLL_CTL_DEL = 2
+	EPOLL_CTL_MOD = 3
+
+	EPOLLIN      = 0x1
+	EPOLLPRI     = 0x2
+	EPOLLOUT     = 0x4
+	EPOLLERR     = 0x8
+	EPOLLHUP     = 0x10
+	EPOLLRDHUP   = 0x2000
+	EPOLLONESHOT = 1 << 30
+	EPOLLET      = 1 << 31
+)
+
+// futex operations and flags.
+const (
+	FUTEX_WAIT            = 0
+	FUTEX_WAKE            = 1
+	FUTEX_FD              = 2
+	FUTEX_REQUEUE         = 3
+	FUTEX_CMP_REQUEUE     = 4
+	FUTEX_WAKE_OP         = 5
+	FUTEX_LOCK_PI         = 6
+	FUTEX_UNLOCK_PI       = 7
+	FUTEX_TRYLOCK_PI      = 8
+	FUTEX_WAIT_BITSET     = 9
+	FUTEX_WAKE_BITSET     = 10
+	FUTEX_WAIT_REQUEUE_PI = 11
+	FUTEX_CMP_REQUEUE_PI  = 12
+	FUTEX_LOCK_PI2        = 13
+
+	FUTEX_PRIVATE_FLAG   = 128
+	FUTEX_CLOCK_REALTIME = 256
+
+	FUTEX_BITSET_MATCH_ANY = 0xffffffff
+)
+
+// futex2 flags.
+const (
+	FUTEX2_SIZE_U8   = 0x00
+	FUTEX2_SIZE_U16  = 0x01
+	FUTEX2_SIZE_U32  = 0x02
+	FUTEX2_SIZE_U64  = 0x03
+	FUTEX2_NUMA      = 0x04
+	FUTEX2_MPOL      = 0x08
+	FUTEX2_PRIVATE   = FUTEX_PRIVATE_FLAG
+	FUTEX2_SIZE_MASK = 0x03
+
+	// FUTEX_32 is FUTEX2_SIZE_U32, the only size the kernel supports yet.
+	FUTEX_32 = FUTEX2_SIZE_U32
+
+	// FUTEX_WAITV_MAX is the maximum number of futexes in a futex_waitv.
+	FUTEX_WAITV_MAX = 128
+)
+
 // io_uring setup flags.
 const (
 	IORING_SETUP_IOPOLL             = 1 << 0
@@ -285,6 +446,13 @@ const (
 	IORING_OP_URING_CMD128     = 64
 )
 
+// io_uring SQ ring flags, read from IoUringSQ.Flags.
+const (
+	IORING_SQ_NEED_WAKEUP = 1 << 0
+	IORING_SQ_CQ_OVERFLOW = 1 << 1
+	IORING_SQ_TASKRUN     = 1 << 2
+)
+
 // io_uring SQE flags.
 const (
 	IOSQE_FIXED_FILE       = 1 << 0
@@ -296,6 +464,159 @@ const (
 	IOSQE_CQE_SKIP_SUCCESS = 1 << 6
 )
 
+// io_uring fsync flags.
+const (
+	IORING_FSYNC_DATASYNC = 1 << 0
+)
+
+// io_uring timeout flags.
+const (
+	IORING_TIMEOUT_ABS           = 1 << 0
+	IORING_TIMEOUT_UPDATE        = 1 << 1
+	IORING_TIMEOUT_BOOTTIME      = 1 << 2
+	IORING_TIMEOUT_REALTIME      = 1 << 3
+	IORING_LINK_TIMEOUT_UPDATE   = 1 << 4
+	IORING_TIMEOUT_ETIME_SUCCESS = 1 << 5
+	IORING_TIMEOUT_MULTISHOT     = 1 << 6
+	IORING_TIMEOUT_CLOCK_MASK    = IORING_TIMEOUT_BOOTTIME | IORING_TIMEOUT_REALTIME
+	IORING_TIMEOUT_UPDATE_MASK   = IORING_TIMEOUT_UPDATE | IORING_LINK_TIMEOUT_UPDATE
+)
+
+// io_uring poll flags, passed in the SQE len field.
+const (
+	IORING_POLL_ADD_MULTI        = 1 << 0
+	IORING_POLL_UPDATE_EVENTS    = 1 << 1
+	IORING_POLL_UPDATE_USER_DATA = 1 << 2
+	IORING_POLL_ADD_LEVEL        = 1 << 3
+)
+
+// io_uring splice flags.
+const (
+	SPLICE_F_FD_IN_FIXED = 1 << 31
+)
+
+// io_uring send/recv flags, passed in IoUringSqe.Ioprio.
+const (
+	IORING_RECVSEND_POLL_FIRST  = 1 << 0
+	IORING_RECV_MULTISHOT       = 1 << 1
+	IORING_RECVSEND_FIXED_BUF   = 1 << 2
+	IORING_SEND_ZC_REPORT_USAGE = 1 << 3
+	IORING_RECVSEND_BUNDLE      = 1 << 4
+)
+
+// io_uring zero-copy notification result flags.
+const (
+	IORING_NOTIF_USAGE_ZC_COPIED = 1 << 31
+)
+
+// io_uring accept flags, passed in IoUringSqe.Ioprio.
+const (
+	IORING_ACCEPT_MULTISHOT  = 1 << 0
+	IORING_ACCEPT_DONTWAIT   = 1 << 1
+	IORING_ACCEPT_POLL_FIRST = 1 << 2
+)
+
+// io_uring msg_ring commands, passed in IoUringSqe.Addr.
+const (
+	IORING_MSG_DATA    = 0
+	IORING_MSG_SEND_FD = 1
+)
+
+// io_uring socket command opcodes for IORING_OP_URING_CMD.
+const (
+	SOCKET_URING_OP_SIOCINQ      = 0
+	SOCKET_URING_OP_SIOCOUTQ     = 1
+	SOCKET_URING_OP_GETSOCKOPT   = 2
+	SOCKET_URING_OP_SETSOCKOPT   = 3
+	SOCKET_URING_OP_TX_TIMESTAMP = 4
+)
+
+// io_uring TX timestamp CQE flags. The timestamp type is a SCM_TSTAMP_*
+// value; the flags sit above IORING_CQE_F_* like a buffer ID.
+const (
+	IORING_TIMESTAMP_HW_SHIFT   = IORING_CQE_BUFFER_SHIFT
+	IORING_TIMESTAMP_TYPE_SHIFT = IORING_TIMESTAMP_HW_SHIFT + 1
+	IORING_CQE_F_TSTAMP_HW      = 1 << IORING_TIMESTAMP_HW_SHIFT
+)
+
+// io_uring cancel flags, for IORING_OP_ASYNC_CANCEL and
+// IORING_REGISTER_SYNC_CANCEL.
+const (
+	IORING_ASYNC_CANCEL_ALL      = 1 << 0
+	IORING_ASYNC_CANCEL_FD       = 1 << 1
+	IORING_ASYNC_CANCEL_ANY      = 1 << 2
+	IORING_ASYNC_CANCEL_FD_FIXED = 1 << 3
+	IORING_ASYNC_CANCEL_USERDATA = 1 << 4
+	IORING_ASYNC_CANCEL_OP       = 1 << 5
+)
+
+// io_uring msg_ring flags.
+const (
+	IORING_MSG_RING_CQE_SKIP   = 1 << 0
+	IORING_MSG_RING_FLAGS_PASS = 1 << 1
+)
+
+// io_uring registered resource flags.
+const (
+	IORING_RSRC_REGISTER_SPARSE = 1 << 0
+
+	// IORING_REGISTER_FILES_SKIP leaves a slot unchanged in a files update.
+	IORING_REGISTER_FILES_SKIP = -2
+
+	// IORING_FILE_INDEX_ALLOC asks the kernel to pick a free slot in the
+	// file allocation range for a direct descriptor.
+	IORING_FILE_INDEX_ALLOC = 0xffffffff
+)
+
+// io_uring fixed fd install flags.
+const (
+	IORING_FIXED_FD_NO_CLOEXEC = 1 << 0
+)
+
+// io_uring memory region flags.
+const (
+	IORING_MEM_REGION_TYPE_USER    = 1 << 0
+	IORING_MEM_REGION_REG_WAIT_ARG = 1 << 0
+)
+
+// io_uring registered wait flags.
+const (
+	IORING_REG_WAIT_TS = 1 << 0
+)
+
+// io_uring NAPI opcodes.
+const (
+	IO_URING_NAPI_REGISTER_OP   = 0
+	IO_URING_NAPI_STATIC_ADD_ID = 1
+	IO_URING_NAPI_STATIC_DEL_ID = 2
+)
+
+// io_uring NAPI tracking strategies.
+const (
+	IO_URING_NAPI_TRACKING_DYNAMIC  = 0
+	IO_URING_NAPI_TRACKING_STATIC   = 1
+	IO_URING_NAPI_TRACKING_INACTIVE = 255
+)
+
+// io_uring CQE flags.
+const (
+	IORING_CQE_F_BUFFER        = 1 << 0
+	IORING_CQE_F_MORE          = 1 << 1
+	IORING_CQE_F_SOCK_NONEMPTY = 1 << 2
+	IORING_CQE_F_NOTIF         = 1 << 3
+	IORING_CQE_F_BUF_MORE      = 1 << 4
+	IORING_CQE_F_SKIP          = 1 << 5
+	IORING_CQE_F_32            = 1 << 15
+
+	IORING_CQE_BUFFER_SHIFT = 16
+)
+
+// io_uring provided buffer ring registration flags.
+const (
+	IOU_PBUF_RING_MMAP = 1
+	IOU_PBUF_RING_INC  = 2
+)
+
 // io_uring register opcodes.
 const (
 	IORING_REGISTER_BUFFERS          = 0
@@ -339,6 +660,51 @@ const (
 	IORING_REGISTER_USE_REGISTERED_RING = 1 << 31
 )
 
+// io_uring restriction opcodes.
+const (
+	IORING_RESTRICTION_REGISTER_OP        = 0
+	IORING_RESTRICTION_SQE_OP             = 1
+	IORING_RESTRICTION_SQE_FLAGS_ALLOWED  = 2
+	IORING_RESTRICTION_SQE_FLAGS_REQUIRED = 3
+)
+
+// io_uring feature flags reported in IoUringParams.Features.
+const (
+	IORING_FEAT_SINGLE_MMAP     = 1 << 0
+	IORING_FEAT_NODROP          = 1 << 1
+	IORING_FEAT_SUBMIT_STABLE   = 1 << 2
+	IORING_FEAT_RW_CUR_POS      = 1 << 3
+	IORING_FEAT_CUR_PERSONALITY = 1 << 4
+	IORING_FEAT_FAST_POLL       = 1 << 5
+	IORING_FEAT_POLL_32BITS     = 1 << 6
+	IORING_FEAT_SQPOLL_NONFIXED = 1 << 7
+	IORING_FEAT_EXT_ARG         = 1 << 8
+	IORING_FEAT_NATIVE_WORKERS  = 1 << 9
+	IORING_FEAT_RSRC_TAGS       = 1 << 10
+	IORING_FEAT_CQE_SKIP        = 1 << 11
+	IORING_FEAT_LINKED_FILE     = 1 << 12
+	IORING_FEAT_REG_REG_RING    = 1 << 13
+	IORING_FEAT_RECVSEND_BUNDLE = 1 << 14
+	IORING_FEAT_MIN_TIMEOUT     = 1 << 15
+	IORING_FEAT_RW_ATTR         = 1 << 16
+	IORING_FEAT_NO_IOWAIT       = 1 << 17
+)
+
+// io_uring probe op flags.
+const (
+	IO_URING_OP_SUPPORTED = 1 << 0
+)
+
+// io_uring mmap offsets.
+const (
+	IORING_OFF_SQ_RING    = 0
+	IORING_OFF_CQ_RING    = 0x8000000
+	IORING_OFF_SQES       = 0x10000000
+	IORING_OFF_PBUF_RING  = 0x80000000
+	IORING_OFF_PBUF_SHIFT = 16
+	IORING_OFF_MMAP_MASK  = 0xf8000000
+)
+
 // mmap protection flags.
 const (
 	PROT_NONE  = 0x0
@@ -354,6 +720,11 @@ const (
 	MAP_FIXED     = 0x10
 	MAP_ANONYMOUS = 0x20
 	MAP_POPULATE  = 0x8000
+	MAP_HUGETLB   = 0x40000
+
+	MAP_HUGE_SHIFT = 26
+	MAP_HUGE_2MB   = 21 << MAP_HUGE_SHIFT
+	MAP_HUGE_1GB   = 30 << MAP_HUGE_SHIFT
 )
 
 // Poll events.
@@ -454,3 +825,257 @@ type Itimerspec struct {
 	Interval Timespec
 	Value    Timespec
 }
+
+// IoSqringOffsets describes the layout of the submission queue ring.
+// Offsets are relative to the start of the SQ ring mapping.
+type IoSqringOffsets struct {
+	Head        uint32
+	Tail        uint32
+	RingMask    uint32
+	RingEntries uint32
+	Flags       uint32
+	Dropped     uint32
+	Array       uint32
+	Resv1       uint32
+	UserAddr    uint64
+}
+
+// IoCqringOffsets describes the layout of the completion queue ring.
+// Offsets are relative to the start of the CQ ring mapping.
+type IoCqringOffsets struct {
+	Head        uint32
+	Tail        uint32
+	RingMask    uint32
+	RingEntries uint32
+	Overflow    uint32
+	Cqes        uint32
+	Flags       uint32
+	Resv1       uint32
+	UserAddr    uint64
+}
+
+// IoUringParams is passed to io_uring_setup.
+// The caller fills in the requested flags; the kernel fills in the
+// ring sizes, features, and ring offsets.
+type IoUringParams struct {
+	SqEntries    uint32
+	CqEntries    uint32
+	Flags        uint32
+	SqThreadCpu  uint32
+	SqThreadIdle uint32
+	Features     uint32
+	WqFd         uint32
+	Resv         [3]uint32
+	SqOff        IoSqringOffsets
+	CqOff        IoCqringOffsets
+}
+
+// OpenHow is the how argument of openat2.
+type OpenHow struct {
+	Flags   uint64
+	Mode    uint64
+	Resolve uint64
+}
+
+// IoUringSqe is an io_uring submission queue entry.
+// Several kernel unions are flattened into one field each; the comments
+// list the union members a field also carries.
+type IoUringSqe struct {
+	Opcode      uint8
+	Flags       uint8
+	Ioprio      uint16
+	Fd          int32
+	Off         uint64 // off, addr2, cmd_op
+	Addr        uint64 // addr, splice_off_in, level/optname
+	Len         uint32
+	OpFlags     uint32 // rw_flags, poll32_events, msg_flags, timeout_flags, ...
+	UserData    uint64
+	BufIndex    uint16 // buf_index, buf_group
+	Personality uint16
+	FileIndex   uint32 // file_index, splice_fd_in, optlen, addr_len
+	Addr3       uint64 // addr3, optval, attr_ptr
+	Pad2        uint64 // attr_type_mask
+}
+
+// IoUringSqe128 is a 128-byte submission queue entry, used by rings
+// created with IORING_SETUP_SQE128.
+type IoUringSqe128 struct {
+	IoUringSqe
+	Ext [64]byte
+}
+
+// IoUringCqe is an io_uring completion queue entry.
+type IoUringCqe struct {
+	UserData uint64
+	Res      int32
+	Flags    uint32
+}
+
+// IoUringCqe32 is a 32-byte completion queue entry, used by rings
+// created with IORING_SETUP_CQE32.
+type IoUringCqe32 struct {
+	IoUringCqe
+	BigCqe [2]uint64
+}
+
+// IoUringProbeOp reports the support status of one opcode.
+type IoUringProbeOp struct {
+	Op    uint8
+	Resv  uint8
+	Flags uint16
+	Resv2 uint32
+}
+
+// IoUringProbe is filled in by IORING_REGISTER_PROBE.
+// Ops has room for every opcode the kernel can report.
+type IoUringProbe struct {
+	LastOp uint8
+	OpsLen uint8
+	Resv   uint16
+	Resv2  [3]uint32
+	Ops    [256]IoUringProbeOp
+}
+
+// IoUringBuf is one entry of a provided buffer ring.
+// The ring tail overlays the Resv field of the first entry.
+type IoUringBuf struct {
+	Addr uint64
+	Len  uint32
+	Bid  uint16
+	Resv uint16
+}
+
+// IoUringBufReg is the argument of IORING_REGISTER_PBUF_RING.
+type IoUringBufReg struct {
+	RingAddr    uint64
+	RingEntries uint32
+	Bgid        uint16
+	Flags       uint16
+	Resv        [3]uint64
+}
+
+// IoUringBufStatus is the argument of IORING_REGISTER_PBUF_STATUS.
+type IoUringBufStatus struct {
+	BufGroup uint32
+	Head     uint32
+	Resv     [8]uint32
+}
+
+// IoUringRsrcRegister is the argument of IORING_REGISTER_FILES2 and
+// IORING_REGISTER_BUFFERS2.
+type IoUringRsrcRegister struct {
+	Nr    uint32
+	Flags uint32
+	Resv2 uint64
+	Data  uint64
+	Tags  uint64
+}
+
+// IoUringRsrcUpdate2 is the argument of IORING_REGISTER_FILES_UPDATE2 and
+// IORING_REGISTER_BUFFERS_UPDATE.
+type IoUringRsrcUpdate2 struct {
+	Offset uint32
+	Resv   uint32
+	Data   uint64
+	Tags   uint64
+	Nr     uint32
+	Resv2  uint32
+}
+
+// IoUringFileIndexRange is the argument of IORING_REGISTER_FILE_ALLOC_RANGE.
+type IoUringFileIndexRange struct {
+	Off  uint32
+	Len  uint32
+	Resv uint64
+}
+
+// IoUringGeteventsArg is the extended argument of io_uring_enter with
+// IORING_ENTER_EXT_ARG.
+type IoUringGeteventsArg struct {
+	Sigmask     uint64
+	SigmaskSz   uint32
+	MinWaitUsec uint32
+	Ts          uint64
+}
+
+// IoUringRegWait is one slot of a registered wait region, selected by
+// io_uring_enter with IORING_ENTER_EXT_ARG_REG.
+type IoUringRegWait struct {
+	Ts          Timespec
+	MinWaitUsec uint32
+	Flags       uint32
+	Sigmask     uint64
+	SigmaskSz   uint32
+	Pad         [3]uint32
+	Pad2        [2]uint64
+}
+
+// IoUringRegionDesc describes a memory region shared with the kernel.
+type IoUringRegionDesc struct {
+	UserAddr   uint64
+	Size       uint64
+	Flags      uint32
+	ID         uint32
+	MmapOffset uint64
+	Resv       [4]uint64
+}
+
+// IoUringMemRegionReg is the argument of IORING_REGISTER_MEM_REGION.
+type IoUringMemRegionReg struct {
+	RegionUptr uint64
+	Flags      uint64
+	Resv       [2]uint64
+}
+
+// IoUringRestriction is one entry of the IORING_REGISTER_RESTRICTIONS array.
+// Arg holds the register_op, sqe_op, or sqe_flags union member selected
+// by Opcode.
+type IoUringRestriction struct {
+	Opcode uint16
+	Arg    uint8
+	Resv   uint8
+	Resv2  [3]uint32
+}
+
+// IoUringRsrcUpdate is the argument of IORING_REGISTER_RING_FDS and
+// IORING_UNREGISTER_RING_FDS.
+type IoUringRsrcUpdate struct {
+	Offset uint32
+	Resv   uint32
+	Data   uint64
+}
+
+// IoUringNapi is the argument of IORING_REGISTER_NAPI and
+// IORING_UNREGISTER_NAPI. OpParam is the tracking strategy for
+// IO_URING_NAPI_REGISTER_OP and the NAPI ID for the static ID opcodes.
+type IoUringNapi struct {
+	BusyPollTo     uint32
+	PreferBusyPoll uint8
+	Opcode         uint8
+	Pad            [2]uint8
+	OpParam        uint32
+	Resv           uint32
+}
+
+// FutexWaiter is a struct futex_waitv, one entry of the array passed to
+// the futex_waitv system call and IORING_OP_FUTEX_WAITV.
+type FutexWaiter struct {
+	Val      uint64
+	Uaddr    uint64
+	Flags    uint32
+	Reserved uint32
+}
+
+// IoUringSyncCancelReg is the argument of IORING_REGISTER_SYNC_CANCEL. A
+// request matches when it satisfies every criterion selected by the
+// IORING_ASYNC_CANCEL_* Flags: Addr is the user data, Fd the file, and
+// Opcode the opcode. A Timeout of {-1, -1} waits indefinitely.
+type IoUringSyncCancelReg struct {
+	Addr    uint64
+	Fd      int32
+	Flags   uint32
+	Timeout Timespec
+	Opcode  uint8
+	Pad     [7]uint8
+	Pad2    [3]uint64
+}
@@ -65,6 +65,16 @@ const (
 	SYS_IO_URING_ENTER    = 426
 	SYS_IO_URING_REGISTER = 427
 
+	// scheduling
+	SYS_SCHED_SETAFFINITY = 122
+	SYS_SCHED_GETAFFINITY = 123
+
+	// futex
+	SYS_FUTEX       = 98
+	SYS_FUTEX_WAITV = 449
+	SYS_FUTEX_WAKE  = 454
+	SYS_FUTEX_WAIT  = 455
+
 	// signalfd, pidfd, memfd
 	SYS_SIGNALFD4         = 74
 	SYS_MEMFD_CREATE      = 279
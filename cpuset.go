@@ -0,0 +1,50 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "math/bits"
+
+// CPUSetSize is the number of CPUs a CPUSet can hold.
+const CPUSetSize = 1024
+
+// CPUSet is a CPU bitmask with the layout of the C cpu_set_t, as used by
+// SchedSetaffinity, SchedGetaffinity, and IORING_REGISTER_IOWQ_AFF.
+// The zero value is the empty set.
+type CPUSet [CPUSetSize / 64]uint64
+
+// Set adds cpu to the set. CPUs outside the set's range are ignored.
+func (s *CPUSet) Set(cpu int) {
+	if uint(cpu) < CPUSetSize {
+		s[cpu/64] |= 1 << (cpu % 64)
+	}
+}
+
+// Clear removes cpu from the set.
+func (s *CPUSet) Clear(cpu int) {
+	if uint(cpu) < CPUSetSize {
+		s[cpu/64] &^= 1 << (cpu % 64)
+	}
+}
+
+// IsSet reports whether cpu is in the set.
+func (s *CPUSet) IsSet(cpu int) bool {
+	return uint(cpu) < CPUSetSize && s[cpu/64]&(1<<(cpu%64)) != 0
+}
+
+// Count returns the number of CPUs in the set.
+func (s *CPUSet) Count() int {
+	n := 0
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Zero empties the set.
+func (s *CPUSet) Zero() {
+	*s = CPUSet{}
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestCPUSet(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.CPUSet{}); got != 128 {
+		t.Fatalf("sizeof(CPUSet) = %d, want 128", got)
+	}
+	var s zcall.CPUSet
+	for _, cpu := range []int{0, 63, 64, 1023} {
+		s.Set(cpu)
+		if !s.IsSet(cpu) {
+			t.Fatalf("CPU %d not set", cpu)
+		}
+	}
+	// Out-of-range CPUs are ignored.
+	s.Set(-1)
+	s.Set(zcall.CPUSetSize)
+	if s.IsSet(-1) || s.IsSet(zcall.CPUSetSize) || s.Count() != 4 {
+		t.Fatalf("Count = %d, want 4", s.Count())
+	}
+	if s[1] != 1 || s[0] != 1|1<<63 {
+		t.Fatalf("words = %#x, %#x", s[0], s[1])
+	}
+	s.Clear(63)
+	if s.IsSet(63) || s.Count() != 3 {
+		t.Fatal("Clear failed")
+	}
+	s.Zero()
+	if s.Count() != 0 {
+		t.Fatal("Zero left CPUs set")
+	}
+}
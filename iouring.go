@@ -0,0 +1,165 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// IoUringSQ is a typed view of a mapped submission queue ring.
+// Scalar fields point directly into kernel-shared memory; Head is
+// written by the kernel and Tail by the application.
+type IoUringSQ struct {
+	Head        *uint32
+	Tail        *uint32
+	RingMask    *uint32
+	RingEntries *uint32
+	Flags       *uint32
+	Dropped     *uint32
+
+	// Array is the SQ index array, or nil when the ring was created
+	// with IORING_SETUP_NO_SQARRAY.
+	Array unsafe.Pointer
+
+	// SQEs is the submission queue entry array.
+	SQEs unsafe.Pointer
+}
+
+// IoUringCQ is a typed view of a mapped completion queue ring.
+// Tail is written by the kernel and Head by the application.
+type IoUringCQ struct {
+	Head        *uint32
+	Tail        *uint32
+	RingMask    *uint32
+	RingEntries *uint32
+	Overflow    *uint32
+	Flags       *uint32
+
+	// CQEs is the completion queue entry array.
+	CQEs unsafe.Pointer
+}
+
+// IoUringRings holds the memory mappings of an io_uring instance.
+// It is returned by IoUringMapRings or IoUringSetupUserMem and released
+// with Unmap.
+type IoUringRings struct {
+	SQ IoUringSQ
+	CQ IoUringCQ
+
+	// SQESize is the size of one SQE in bytes (64, or 128 with IORING_SETUP_SQE128).
+	SQESize uintptr
+	// CQESize is the size of one CQE in bytes (16, or 32 with IORING_SETUP_CQE32).
+	CQESize uintptr
+	// SetupFlags are the IORING_SETUP_* flags the ring was created with.
+	SetupFlags uint32
+
+	sqRing     unsafe.Pointer
+	cqRing     unsafe.Pointer
+	sqes       unsafe.Pointer
+	sqRingSize uintptr
+	cqRingSize uintptr
+	sqesSize   uintptr
+}
+
+// IoUringMapRings maps the SQ ring, CQ ring, and SQE array of the io_uring
+// instance fd, using the sizes and offsets the kernel wrote to p during
+// IoUringSetup. When the kernel reports IORING_FEAT_SINGLE_MMAP, the SQ and
+// CQ rings share a single mapping.
+// On failure, any mapping already established is released.
+func IoUringMapRings(fd uintptr, p *IoUringParams) (r IoUringRings, errno uintptr) {
+	r.SetupFlags = p.Flags
+	r.SQESize = unsafe.Sizeof(IoUringSqe{})
+	if p.Flags&IORING_SETUP_SQE128 != 0 {
+		r.SQESize *= 2
+	}
+	r.CQESize = unsafe.Sizeof(IoUringCqe{})
+	if p.Flags&IORING_SETUP_CQE32 != 0 {
+		r.CQESize *= 2
+	}
+
+	r.sqRingSize = uintptr(p.SqOff.Array) + uintptr(p.SqEntries)*4
+	r.cqRingSize = uintptr(p.CqOff.Cqes) + uintptr(p.CqEntries)*r.CQESize
+	single := p.Features&IORING_FEAT_SINGLE_MMAP != 0
+	if single {
+		r.sqRingSize = max(r.sqRingSize, r.cqRingSize)
+		r.cqRingSize = r.sqRingSize
+	}
+
+	const prot = PROT_READ | PROT_WRITE
+	const flags = MAP_SHARED | MAP_POPULATE
+	r.sqRing, errno = Mmap(nil, r.sqRingSize, prot, flags, fd, IORING_OFF_SQ_RING)
+	if errno != 0 {
+		return IoUringRings{}, errno
+	}
+	if single {
+		r.cqRing = r.sqRing
+	} else {
+		r.cqRing, errno = Mmap(nil, r.cqRingSize, prot, flags, fd, IORING_OFF_CQ_RING)
+		if errno != 0 {
+			Munmap(r.sqRing, r.sqRingSize)
+			return IoUringRings{}, errno
+		}
+	}
+	r.sqesSize = uintptr(p.SqEntries) * r.SQESize
+	r.sqes, errno = Mmap(nil, r.sqesSize, prot, flags, fd, IORING_OFF_SQES)
+	if errno != 0 {
+		if !single {
+			Munmap(r.cqRing, r.cqRingSize)
+		}
+		Munmap(r.sqRing, r.sqRingSize)
+		return IoUringRings{}, errno
+	}
+
+	r.initViews(p)
+	return r, 0
+}
+
+// initViews points the SQ and CQ views into the mapped ring memory.
+func (r *IoUringRings) initViews(p *IoUringParams) {
+	sq, cq := r.sqRing, r.cqRing
+	r.SQ = IoUringSQ{
+		Head:        (*uint32)(unsafe.Add(sq, p.SqOff.Head)),
+		Tail:        (*uint32)(unsafe.Add(sq, p.SqOff.Tail)),
+		RingMask:    (*uint32)(unsafe.Add(sq, p.SqOff.RingMask)),
+		RingEntries: (*uint32)(unsafe.Add(sq, p.SqOff.RingEntries)),
+		Flags:       (*uint32)(unsafe.Add(sq, p.SqOff.Flags)),
+		Dropped:     (*uint32)(unsafe.Add(sq, p.SqOff.Dropped)),
+		SQEs:        r.sqes,
+	}
+	if p.Flags&IORING_SETUP_NO_SQARRAY == 0 {
+		r.SQ.Array = unsafe.Add(sq, p.SqOff.Array)
+	}
+	r.CQ = IoUringCQ{
+		Head:        (*uint32)(unsafe.Add(cq, p.CqOff.Head)),
+		Tail:        (*uint32)(unsafe.Add(cq, p.CqOff.Tail)),
+		RingMask:    (*uint32)(unsafe.Add(cq, p.CqOff.RingMask)),
+		RingEntries: (*uint32)(unsafe.Add(cq, p.CqOff.RingEntries)),
+		Overflow:    (*uint32)(unsafe.Add(cq, p.CqOff.Overflow)),
+		Flags:       (*uint32)(unsafe.Add(cq, p.CqOff.Flags)),
+		CQEs:        unsafe.Add(cq, p.CqOff.Cqes),
+	}
+}
+
+// Unmap releases the ring mappings. The views must not be used afterwards.
+// It returns the first errno encountered, if any.
+func (r *IoUringRings) Unmap() (errno uintptr) {
+	if r.sqes != nil {
+		errno = Munmap(r.sqes, r.sqesSize)
+	}
+	// Rings set up by IoUringSetupUserMem live inside the SQE mapping and
+	// have no mapping of their own.
+	if r.cqRing != nil && r.cqRing != r.sqRing && r.cqRingSize != 0 {
+		if e := Munmap(r.cqRing, r.cqRingSize); errno == 0 {
+			errno = e
+		}
+	}
+	if r.sqRing != nil && r.sqRingSize != 0 {
+		if e := Munmap(r.sqRing, r.sqRingSize); errno == 0 {
+			errno = e
+		}
+	}
+	*r = IoUringRings{}
+	return errno
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// hugeMmap rounds size up to a multiple of pageSize and returns the Mmap
+// flags selecting huge pages of that size. pageSize must be a power of two.
+func hugeMmap(size, pageSize uintptr) (rounded, flags uintptr, errno uintptr) {
+	if pageSize == 0 || pageSize&(pageSize-1) != 0 {
+		return 0, 0, uintptr(EINVAL)
+	}
+	shift := uintptr(bits.TrailingZeros64(uint64(pageSize)))
+	return (size + pageSize - 1) &^ (pageSize - 1), MAP_HUGETLB | shift<<MAP_HUGE_SHIFT, 0
+}
+
+// FixedBuffers is a set of buffers registered with an io_uring instance
+// through IORING_REGISTER_BUFFERS2, for use with IORING_OP_READ_FIXED,
+// IORING_OP_WRITE_FIXED, IORING_OP_READV_FIXED, and IORING_OP_WRITEV_FIXED.
+//
+// Each slot carries a tag. When a slot with a nonzero tag is replaced by
+// Update or unregistered by Close, the kernel posts a completion with
+// UserData set to the old tag once the buffer is no longer in use, so tags
+// must not collide with the user data of ordinary requests.
+//
+// The backing memory is allocated with Mmap and is never moved by the Go
+// runtime. A FixedBuffers must be used by a single goroutine at a time.
+type FixedBuffers struct {
+	fd      uintptr
+	mem     unsafe.Pointer
+	size    uintptr
+	bufSize uintptr
+	iovs    []Iovec
+	tags    []uint64
+}
+
+// NewFixedBuffers maps nr buffers of bufSize bytes each and registers them
+// with the io_uring instance fd. tags is either nil or holds one tag per
+// buffer. A nonzero hugePageSize backs the memory with MAP_HUGETLB pages of
+// that size, such as 2 MiB or 1 GiB, which must have been reserved by the
+// administrator; the kernel reports EINVAL for a size it does not support.
+func NewFixedBuffers(fd uintptr, nr uint32, bufSize uintptr, tags []uint64, hugePageSize uintptr) (fb *FixedBuffers, errno uintptr) {
+	if nr == 0 || bufSize == 0 || (tags != nil && len(tags) != int(nr)) {
+		return nil, uintptr(EINVAL)
+	}
+	size := uintptr(nr) * bufSize
+	flags := uintptr(MAP_PRIVATE | MAP_ANONYMOUS | MAP_POPULATE)
+	if hugePageSize != 0 {
+		var huge uintptr
+		if size, huge, errno = hugeMmap(size, hugePageSize); errno != 0 {
+			return nil, errno
+		}
+		flags |= huge
+	}
+	mem, errno := Mmap(nil, size, PROT_READ|PROT_WRITE, flags, ^uintptr(0), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	fb = &FixedBuffers{
+		fd:      fd,
+		mem:     mem,
+		size:    size,
+		bufSize: bufSize,
+		iovs:    make([]Iovec, nr),
+		tags:    make([]uint64, nr),
+	}
+	for i := range fb.iovs {
+		fb.iovs[i] = Iovec{Base: (*byte)(unsafe.Add(mem, uintptr(i)*bufSize)), Len: uint64(bufSize)}
+	}
+	copy(fb.tags, tags)
+
+	reg := IoUringRsrcRegister{
+		Nr:   nr,
+		Data: uint64(uintptr(unsafe.Pointer(&fb.iovs[0]))),
+		Tags: uint64(uintptr(unsafe.Pointer(&fb.tags[0]))),
+	}
+	if _, errno = IoUringRegister(fd, IORING_REGISTER_BUFFERS2, unsafe.Pointer(&reg), unsafe.Sizeof(reg)); errno != 0 {
+		Munmap(mem, size)
+		return nil, errno
+	}
+	return fb, 0
+}
+
+// Len returns the number of registered slots.
+func (fb *FixedBuffers) Len() int {
+	return len(fb.iovs)
+}
+
+// Buf returns the memory currently registered in slot index. Slices of it
+// may be passed to PrepReadFixed and PrepWriteFixed with bufIndex index.
+func (fb *FixedBuffers) Buf(index uint16) []byte {
+	iov := fb.iovs[index]
+	return unsafe.Slice(iov.Base, iov.Len)
+}
+
+// Tag returns the tag of slot index.
+func (fb *FixedBuffers) Tag(index uint16) uint64 {
+	return fb.tags[index]
+}
+
+// Update replaces slot index with the buffer iov and tag through
+// IORING_REGISTER_BUFFERS_UPDATE. The memory of iov must stay valid until
+// the slot is replaced again and its release completion has been posted.
+// A zero iov leaves the slot empty. Passing the Iovec of the slot's own
+// memory, as returned by Slot, restores it.
+func (fb *FixedBuffers) Update(index uint16, iov Iovec, tag uint64) (errno uintptr) {
+	up := IoUringRsrcUpdate2{
+		Offset: uint32(index),
+		Data:   uint64(uintptr(unsafe.Pointer(&iov))),
+		Tags:   uint64(uintptr(unsafe.Pointer(&tag))),
+		Nr:     1,
+	}
+	_, errno = IoUringRegister(fb.fd, IORING_REGISTER_BUFFERS_UPDATE, unsafe.Pointer(&up), unsafe.Sizeof(up))
+	if errno != 0 {
+		return errno
+	}
+	fb.iovs[index], fb.tags[index] = iov, tag
+	return 0
+}
+
+// Slot returns the Iovec of the memory NewFixedBuffers mapped for slot
+// index, regardless of what is currently registered there.
+func (fb *FixedBuffers) Slot(index uint16) Iovec {
+	return Iovec{Base: (*byte)(unsafe.Add(fb.mem, uintptr(index)*fb.bufSize)), Len: uint64(fb.bufSize)}
+}
+
+// Close unregisters the buffers with IORING_UNREGISTER_BUFFERS and releases
+// the mapped memory. It returns the first errno encountered, if any.
+func (fb *FixedBuffers) Close() (errno uintptr) {
+	if fb.mem == nil {
+		return 0
+	}
+	_, errno = IoUringRegister(fb.fd, IORING_UNREGISTER_BUFFERS, nil, 0)
+	if e := Munmap(fb.mem, fb.size); errno == 0 {
+		errno = e
+	}
+	fb.mem = nil
+	return errno
+}
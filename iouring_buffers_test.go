@@ -0,0 +1,127 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestNewFixedBuffersInvalid(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	if _, errno := zcall.NewFixedBuffers(r.fd, 0, 4096, nil, 0); zcall.Errno(errno) != zcall.EINVAL {
+		t.Errorf("nr=0 errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+	if _, errno := zcall.NewFixedBuffers(r.fd, 2, 4096, []uint64{1}, 0); zcall.Errno(errno) != zcall.EINVAL {
+		t.Errorf("short tags errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+	if _, errno := zcall.NewFixedBuffers(r.fd, 1, 4096, nil, 3<<20); zcall.Errno(errno) != zcall.EINVAL {
+		t.Errorf("huge page size 3 MiB errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+func TestFixedBuffersReadWrite(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	_, fd := testFile(t)
+	fb, errno := zcall.NewFixedBuffers(r.fd, 2, 4096, []uint64{11, 12}, 0)
+	if errno != 0 {
+		t.Fatalf("NewFixedBuffers failed: %v", zcall.Errno(errno))
+	}
+	defer fb.Close()
+	if fb.Len() != 2 || fb.Tag(1) != 12 {
+		t.Fatalf("Len, Tag(1) = %d, %d; want 2, 12", fb.Len(), fb.Tag(1))
+	}
+
+	src, dst := fb.Buf(0), fb.Buf(1)
+	copy(src, "registered")
+	expectRes(t, "WRITE_FIXED", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepWriteFixed(sqe, fd, src[:10], 0, 0) }), 10)
+	expectRes(t, "READ_FIXED", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepReadFixed(sqe, fd, dst[:10], 0, 1) }), 10)
+	if string(dst[:10]) != "registered" {
+		t.Fatalf("READ_FIXED data = %q", dst[:10])
+	}
+
+	// A buffer outside the registered slot is rejected.
+	other := make([]byte, 10)
+	expectRes(t, "READ_FIXED foreign", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepReadFixed(sqe, fd, other, 0, 1) }), -int32(zcall.EFAULT))
+
+	riov := []zcall.Iovec{{Base: &dst[100], Len: 4}, {Base: &dst[200], Len: 6}}
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepReadvFixed(sqe, fd, unsafe.Pointer(&riov[0]), 2, 0, 1) })
+	skipIfUnsupported(t, "READV_FIXED", cqe)
+	expectRes(t, "READV_FIXED", cqe, 10)
+	if got := string(dst[100:104]) + string(dst[200:206]); got != "registered" {
+		t.Fatalf("READV_FIXED data = %q", got)
+	}
+	wiov := []zcall.Iovec{{Base: &src[0], Len: 10}}
+	expectRes(t, "WRITEV_FIXED", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWritevFixed(sqe, fd, unsafe.Pointer(&wiov[0]), 1, 10, 0)
+	}), 10)
+}
+
+func TestFixedBuffersUpdateReleaseTag(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	_, fd := testFile(t)
+	const oldTag, newTag = 0xa1, 0xb2
+	fb, errno := zcall.NewFixedBuffers(r.fd, 2, 4096, []uint64{0, oldTag}, 0)
+	if errno != 0 {
+		t.Fatalf("NewFixedBuffers failed: %v", zcall.Errno(errno))
+	}
+	defer fb.Close()
+
+	// Move slot 1 onto the memory of slot 0.
+	if errno := fb.Update(1, fb.Slot(0), newTag); errno != 0 {
+		t.Fatalf("Update failed: %v", zcall.Errno(errno))
+	}
+	if fb.Tag(1) != newTag || &fb.Buf(1)[0] != &fb.Buf(0)[0] {
+		t.Fatal("Update did not record the new slot")
+	}
+
+	// The replaced buffer is released with its tag as user data.
+	r.enter(1)
+	cqe, ok := r.pop()
+	if !ok {
+		t.Fatal("no release completion")
+	}
+	if cqe.UserData != oldTag || cqe.Res != 0 {
+		t.Fatalf("release cqe = {UserData: %#x, Res: %d}, want {%#x, 0}", cqe.UserData, cqe.Res, oldTag)
+	}
+
+	copy(fb.Buf(0), "shared")
+	expectRes(t, "WRITE_FIXED via slot 1", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWriteFixed(sqe, fd, fb.Buf(1)[:6], 0, 1)
+	}), 6)
+
+	// An empty slot rejects I/O.
+	if errno := fb.Update(1, zcall.Iovec{}, 0); errno != 0 {
+		t.Fatalf("Update to empty failed: %v", zcall.Errno(errno))
+	}
+	r.enter(1)
+	if cqe, ok := r.pop(); !ok || cqe.UserData != newTag {
+		t.Fatalf("release cqe = %+v, %v; want UserData %#x", cqe, ok, newTag)
+	}
+	expectRes(t, "WRITE_FIXED empty slot", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWriteFixed(sqe, fd, fb.Buf(0)[:6], 0, 1)
+	}), -int32(zcall.EFAULT))
+}
+
+func TestFixedBuffersHugePages(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	fb, errno := zcall.NewFixedBuffers(r.fd, 1, 1<<20, nil, 2<<20)
+	switch zcall.Errno(errno) {
+	case zcall.ENOMEM:
+		t.Skip("no huge pages reserved")
+	case zcall.EINVAL:
+		t.Skip("2 MiB huge pages not supported")
+	}
+	if errno != 0 {
+		t.Fatalf("NewFixedBuffers failed: %v", zcall.Errno(errno))
+	}
+	if errno := fb.Close(); errno != 0 {
+		t.Fatalf("Close failed: %v", zcall.Errno(errno))
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// maxBufRingEntries is the largest ring the kernel accepts.
+const maxBufRingEntries = 1 << 15
+
+// BufRing is a provided buffer ring registered with an io_uring instance
+// under a buffer group id. Requests that set IOSQE_BUFFER_SELECT and name
+// the group in IoUringSqe.BufIndex take their buffer from the ring, so
+// multishot receives need no per-operation buffer.
+//
+// The ring memory is allocated with Mmap and is never moved by the Go
+// runtime. Buffers added to the ring must stay valid while the kernel may
+// pick them. A BufRing must be used by a single goroutine at a time.
+type BufRing struct {
+	fd      uintptr
+	ring    unsafe.Pointer
+	size    uintptr
+	mask    uint16
+	entries uint32
+	bgid    uint16
+	flags   uint16
+	tail    uint16
+}
+
+// NewBufRing allocates a ring of entries buffers and registers it with the
+// io_uring instance fd as buffer group bgid. entries must be a power of two
+// no larger than 32768. flags may include IOU_PBUF_RING_INC to let the
+// kernel consume buffers incrementally; IOU_PBUF_RING_MMAP is not supported
+// because the ring memory is always provided by the application.
+func NewBufRing(fd uintptr, entries uint32, bgid uint16, flags uint16) (br *BufRing, errno uintptr) {
+	if entries == 0 || entries > maxBufRingEntries || entries&(entries-1) != 0 || flags&IOU_PBUF_RING_MMAP != 0 {
+		return nil, uintptr(EINVAL)
+	}
+	size := uintptr(entries) * unsafe.Sizeof(IoUringBuf{})
+	ring, errno := Mmap(nil, size, PROT_READ|PROT_WRITE, MAP_PRIVATE|MAP_ANONYMOUS|MAP_POPULATE, ^uintptr(0), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	reg := IoUringBufReg{
+		RingAddr:    uint64(uintptr(ring)),
+		RingEntries: entries,
+		Bgid:        bgid,
+		Flags:       flags,
+	}
+	if _, errno = IoUringRegister(fd, IORING_REGISTER_PBUF_RING, unsafe.Pointer(&reg), 1); errno != 0 {
+		Munmap(ring, size)
+		return nil, errno
+	}
+	return &BufRing{
+		fd:      fd,
+		ring:    ring,
+		size:    size,
+		mask:    uint16(entries - 1),
+		entries: entries,
+		bgid:    bgid,
+		flags:   flags,
+	}, 0
+}
+
+// Add writes buffer bid, of length bytes at addr, into the ring slot offset
+// entries past the current tail. The buffer is not visible to the kernel
+// until Advance moves the tail past it.
+func (br *BufRing) Add(addr unsafe.Pointer, length uint32, bid uint16, offset uint16) {
+	idx := (br.tail + offset) & br.mask
+	buf := (*IoUringBuf)(unsafe.Add(br.ring, uintptr(idx)*unsafe.Sizeof(IoUringBuf{})))
+	// Resv of the first entry is the shared tail; leave it alone.
+	buf.Addr = uint64(uintptr(addr))
+	buf.Len = length
+	buf.Bid = bid
+}
+
+// Advance publishes count buffers written by Add to the kernel.
+func (br *BufRing) Advance(count uint16) {
+	br.tail += count
+	// The 16-bit tail shares a 32-bit word with the Bid of the first entry,
+	// which only the application writes. Storing the whole word gives the
+	// tail release semantics on the little-endian targets io_uring supports.
+	word := (*uint32)(unsafe.Add(br.ring, unsafe.Offsetof(IoUringBuf{}.Bid)))
+	bid0 := (*IoUringBuf)(br.ring).Bid
+	atomic.StoreUint32(word, uint32(bid0)|uint32(br.tail)<<16)
+}
+
+// Head queries the kernel with IORING_REGISTER_PBUF_STATUS and returns the
+// ring head, the index of the next buffer the kernel will consume.
+func (br *BufRing) Head() (head uint16, errno uintptr) {
+	status := IoUringBufStatus{BufGroup: uint32(br.bgid)}
+	_, errno = IoUringRegister(br.fd, IORING_REGISTER_PBUF_STATUS, unsafe.Pointer(&status), 1)
+	return uint16(status.Head), errno
+}
+
+// BufGroup returns the buffer group id the ring is registered under.
+func (br *BufRing) BufGroup() uint16 {
+	return br.bgid
+}
+
+// Entries returns the number of slots in the ring.
+func (br *BufRing) Entries() uint32 {
+	return br.entries
+}
+
+// Incremental reports whether the ring was registered with IOU_PBUF_RING_INC.
+// The kernel then consumes a buffer in pieces: each completion covers the
+// next bytes of the buffer, and IORING_CQE_F_BUF_MORE marks completions
+// after which the buffer is still in use and must not be re-added.
+func (br *BufRing) Incremental() bool {
+	return br.flags&IOU_PBUF_RING_INC != 0
+}
+
+// Close unregisters the ring with IORING_UNREGISTER_PBUF_RING and releases
+// its memory. It returns the first errno encountered, if any.
+func (br *BufRing) Close() (errno uintptr) {
+	if br.ring == nil {
+		return 0
+	}
+	reg := IoUringBufReg{Bgid: br.bgid}
+	_, errno = IoUringRegister(br.fd, IORING_UNREGISTER_PBUF_RING, unsafe.Pointer(&reg), 1)
+	if e := Munmap(br.ring, br.size); errno == 0 {
+		errno = e
+	}
+	br.ring = nil
+	return errno
+}
+
+// BufferID returns the id of the provided buffer the completion consumed.
+// ok is false when the completion carries no buffer (IORING_CQE_F_BUFFER
+// is clear).
+func (cqe *IoUringCqe) BufferID() (bid uint16, ok bool) {
+	if cqe.Flags&IORING_CQE_F_BUFFER == 0 {
+		return 0, false
+	}
+	return uint16(cqe.Flags >> IORING_CQE_BUFFER_SHIFT), true
+}
+
+// BufferMore reports whether the provided buffer of an incrementally
+// consumed ring remains in use after this completion (IORING_CQE_F_BUF_MORE).
+func (cqe *IoUringCqe) BufferMore() bool {
+	return cqe.Flags&IORING_CQE_F_BUF_MORE != 0
+}
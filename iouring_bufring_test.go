@@ -0,0 +1,179 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// testBufArea maps size bytes of anonymous memory for provided buffers.
+func testBufArea(t *testing.T, size uintptr) []byte {
+	t.Helper()
+	mem, errno := zcall.Mmap(nil, size, zcall.PROT_READ|zcall.PROT_WRITE, zcall.MAP_PRIVATE|zcall.MAP_ANONYMOUS, ^uintptr(0), 0)
+	if errno != 0 {
+		t.Fatalf("Mmap failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { zcall.Munmap(mem, size) })
+	return unsafe.Slice((*byte)(mem), size)
+}
+
+// newTestBufRing registers a buffer ring, skipping if the kernel lacks it.
+func newTestBufRing(t *testing.T, fd uintptr, entries uint32, bgid, flags uint16) *zcall.BufRing {
+	t.Helper()
+	br, errno := zcall.NewBufRing(fd, entries, bgid, flags)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("provided buffer ring flags not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("NewBufRing failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { br.Close() })
+	return br
+}
+
+// prepRecvSelect prepares a RECV that picks its buffer from group bgid.
+func prepRecvSelect(fd int32, bgid uint16) func(sqe *zcall.IoUringSqe) {
+	return func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRecv(sqe, fd, nil, 0)
+		sqe.Flags |= zcall.IOSQE_BUFFER_SELECT
+		sqe.BufIndex = bgid
+	}
+}
+
+func TestBufRingLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringBuf{}); got != 16 {
+		t.Errorf("sizeof(IoUringBuf) = %d, want 16", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringBufReg{}); got != 40 {
+		t.Errorf("sizeof(IoUringBufReg) = %d, want 40", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringBufStatus{}); got != 40 {
+		t.Errorf("sizeof(IoUringBufStatus) = %d, want 40", got)
+	}
+}
+
+func TestNewBufRingInvalid(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	tests := []struct {
+		name    string
+		entries uint32
+		flags   uint16
+	}{
+		{"zero", 0, 0},
+		{"not_power_of_two", 3, 0},
+		{"too_large", 1 << 16, 0},
+		{"kernel_mmap", 8, zcall.IOU_PBUF_RING_MMAP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, errno := zcall.NewBufRing(r.fd, tt.entries, 0, tt.flags); zcall.Errno(errno) != zcall.EINVAL {
+				t.Fatalf("NewBufRing errno = %v, want EINVAL", zcall.Errno(errno))
+			}
+		})
+	}
+}
+
+func TestBufRingRecv(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testSocketpair(t)
+	const bgid, bufSize, nbufs = 7, 64, 4
+	br := newTestBufRing(t, r.fd, nbufs, bgid, 0)
+	area := testBufArea(t, bufSize*nbufs)
+
+	// An empty ring has no buffer to pick.
+	zcall.Write(uintptr(a), []byte("x"))
+	expectRes(t, "RECV empty ring", r.run(prepRecvSelect(b, bgid)), -int32(zcall.ENOBUFS))
+
+	for i := range uint16(nbufs) {
+		br.Add(unsafe.Pointer(&area[int(i)*bufSize]), bufSize, i, i)
+	}
+	br.Advance(nbufs)
+
+	cqe := r.run(prepRecvSelect(b, bgid))
+	expectRes(t, "RECV", cqe, 1)
+	bid, ok := cqe.BufferID()
+	if !ok || bid != 0 {
+		t.Fatalf("BufferID = %d, %v; want 0, true", bid, ok)
+	}
+
+	zcall.Write(uintptr(a), []byte("hello"))
+	cqe = r.run(prepRecvSelect(b, bgid))
+	expectRes(t, "RECV", cqe, 5)
+	bid, ok = cqe.BufferID()
+	if !ok || bid != 1 {
+		t.Fatalf("BufferID = %d, %v; want 1, true", bid, ok)
+	}
+	if got := string(area[bufSize : bufSize+5]); got != "hello" {
+		t.Fatalf("buffer 1 data = %q, want %q", got, "hello")
+	}
+
+	head, errno := br.Head()
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_PBUF_STATUS not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("Head failed: %v", zcall.Errno(errno))
+	}
+	if head != 2 {
+		t.Fatalf("Head = %d, want 2", head)
+	}
+
+	// Recycle buffer 0 behind the remaining ones.
+	br.Add(unsafe.Pointer(&area[0]), bufSize, 0, 0)
+	br.Advance(1)
+	for _, want := range []uint16{2, 3, 0} {
+		zcall.Write(uintptr(a), []byte("z"))
+		cqe = r.run(prepRecvSelect(b, bgid))
+		if bid, _ := cqe.BufferID(); bid != want {
+			t.Fatalf("BufferID = %d, want %d", bid, want)
+		}
+	}
+}
+
+func TestBufRingIncremental(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testSocketpair(t)
+	const bgid, bufSize = 3, 64
+	br := newTestBufRing(t, r.fd, 1, bgid, zcall.IOU_PBUF_RING_INC)
+	if !br.Incremental() {
+		t.Fatal("Incremental = false")
+	}
+	area := testBufArea(t, bufSize)
+	br.Add(unsafe.Pointer(&area[0]), bufSize, 5, 0)
+	br.Advance(1)
+
+	off := 0
+	for _, msg := range []string{"abc", "defgh"} {
+		zcall.Write(uintptr(a), []byte(msg))
+		cqe := r.run(prepRecvSelect(b, bgid))
+		expectRes(t, "RECV", cqe, int32(len(msg)))
+		if bid, ok := cqe.BufferID(); !ok || bid != 5 {
+			t.Fatalf("BufferID = %d, %v; want 5, true", bid, ok)
+		}
+		if !cqe.BufferMore() {
+			t.Fatal("IORING_CQE_F_BUF_MORE not set on partial consumption")
+		}
+		if got := string(area[off : off+len(msg)]); got != msg {
+			t.Fatalf("data at %d = %q, want %q", off, got, msg)
+		}
+		off += len(msg)
+	}
+}
+
+func TestIoUringCqeBufferID(t *testing.T) {
+	cqe := zcall.IoUringCqe{Flags: 9 << zcall.IORING_CQE_BUFFER_SHIFT}
+	if _, ok := cqe.BufferID(); ok {
+		t.Fatal("BufferID ok without IORING_CQE_F_BUFFER")
+	}
+	cqe.Flags |= zcall.IORING_CQE_F_BUFFER
+	if bid, ok := cqe.BufferID(); !ok || bid != 9 {
+		t.Fatalf("BufferID = %d, %v; want 9, true", bid, ok)
+	}
+}
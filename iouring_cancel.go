@@ -0,0 +1,26 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// CancelSync cancels the requests of the io_uring instance fd that match
+// the criteria of reg and waits until they have completed. A nil timeout
+// waits indefinitely; otherwise the wait is bounded by the relative
+// timeout, and reg.Timeout is ignored. With IORING_ASYNC_CANCEL_ALL or
+// IORING_ASYNC_CANCEL_ANY, n is the number of requests cancelled.
+//
+// errno is ENOENT when no request matched a single-request cancellation,
+// and ETIME when a matched request was still running at the timeout.
+func CancelSync(fd uintptr, reg *IoUringSyncCancelReg, timeout *Timespec) (n uintptr, errno uintptr) {
+	arg := *reg
+	arg.Timeout = Timespec{Sec: -1, Nsec: -1}
+	if timeout != nil {
+		arg.Timeout = *timeout
+	}
+	return IoUringRegister(fd, IORING_REGISTER_SYNC_CANCEL, unsafe.Pointer(&arg), 1)
+}
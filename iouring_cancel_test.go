@@ -0,0 +1,158 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestIoUringSyncCancelRegLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringSyncCancelReg{}); got != 64 {
+		t.Errorf("sizeof(IoUringSyncCancelReg) = %d, want 64", got)
+	}
+}
+
+// expectCancelled drains n completions and checks that each was cancelled.
+func expectCancelled(t *testing.T, r *testRing, n int) map[uint64]bool {
+	t.Helper()
+	seen := make(map[uint64]bool)
+	for range n {
+		cqe, ok := r.pop()
+		if !ok {
+			t.Fatalf("%d completions, want %d", len(seen), n)
+		}
+		expectRes(t, "cancelled request", cqe, -int32(zcall.ECANCELED))
+		seen[cqe.UserData] = true
+	}
+	if cqe, ok := r.pop(); ok {
+		t.Fatalf("unexpected completion %+v", cqe)
+	}
+	return seen
+}
+
+func TestCancelSync(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+	pfd, _ := testPipe(t)
+	buf := make([]byte, 8)
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, rfd, buf, 0) })
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, rfd, buf, 0) })
+	r.push(3, func(sqe *zcall.IoUringSqe) { zcall.PrepPollAdd(sqe, pfd, zcall.POLLIN) })
+	r.enter(0)
+
+	_, errno := zcall.CancelSync(r.fd, &zcall.IoUringSyncCancelReg{Addr: 1}, nil)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_SYNC_CANCEL not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("CancelSync by user data failed: %v", zcall.Errno(errno))
+	}
+	// The request is gone by the time CancelSync returns.
+	if seen := expectCancelled(t, r, 1); !seen[1] {
+		t.Fatalf("cancelled %v, want user data 1", seen)
+	}
+	if _, errno := zcall.CancelSync(r.fd, &zcall.IoUringSyncCancelReg{Addr: 1}, nil); zcall.Errno(errno) != zcall.ENOENT {
+		t.Fatalf("CancelSync of a finished request errno = %v, want ENOENT", zcall.Errno(errno))
+	}
+
+	ts := zcall.Timespec{Sec: 1}
+	n, errno := zcall.CancelSync(r.fd, &zcall.IoUringSyncCancelReg{
+		Opcode: zcall.IORING_OP_POLL_ADD,
+		Flags:  zcall.IORING_ASYNC_CANCEL_OP | zcall.IORING_ASYNC_CANCEL_ALL,
+	}, &ts)
+	if errno != 0 || n != 1 {
+		t.Fatalf("CancelSync by opcode = %d, %v; want 1", n, zcall.Errno(errno))
+	}
+	if seen := expectCancelled(t, r, 1); !seen[3] {
+		t.Fatalf("cancelled %v, want user data 3", seen)
+	}
+
+	// ANY matches every request and reports how many it cancelled.
+	n, errno = zcall.CancelSync(r.fd, &zcall.IoUringSyncCancelReg{Flags: zcall.IORING_ASYNC_CANCEL_ANY}, &ts)
+	if errno != 0 || n != 1 {
+		t.Fatalf("CancelSync ANY = %d, %v; want 1", n, zcall.Errno(errno))
+	}
+	expectCancelled(t, r, 1)
+}
+
+func TestCancelSyncFixedFd(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+	files := newTestFileTable(t, r, 2)
+	if _, errno := files.Update(1, []int32{rfd}); errno != 0 {
+		t.Fatalf("Update failed: %v", zcall.Errno(errno))
+	}
+	buf := make([]byte, 8)
+	for ud := range uint64(2) {
+		r.push(ud, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepRead(sqe, 1, buf, 0)
+			sqe.Flags |= zcall.IOSQE_FIXED_FILE
+		})
+	}
+	r.enter(0)
+	n, errno := zcall.CancelSync(r.fd, &zcall.IoUringSyncCancelReg{
+		Fd:    1,
+		Flags: zcall.IORING_ASYNC_CANCEL_FD | zcall.IORING_ASYNC_CANCEL_FD_FIXED | zcall.IORING_ASYNC_CANCEL_ALL,
+	}, nil)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_SYNC_CANCEL not supported on this kernel")
+	}
+	if errno != 0 || n != 2 {
+		t.Fatalf("CancelSync by fixed fd = %d, %v; want 2", n, zcall.Errno(errno))
+	}
+	expectCancelled(t, r, 2)
+}
+
+func TestPrepAsyncCancel(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+	buf := make([]byte, 8)
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, rfd, buf, 0) })
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, rfd, buf, 0) })
+	r.push(3, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepAsyncCancel(sqe, &zcall.IoUringSyncCancelReg{
+			Fd:     rfd,
+			Opcode: zcall.IORING_OP_READ,
+			Flags:  zcall.IORING_ASYNC_CANCEL_FD | zcall.IORING_ASYNC_CANCEL_OP | zcall.IORING_ASYNC_CANCEL_ALL,
+		})
+	})
+	r.enter(3)
+	for range 3 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 1, 2:
+			expectRes(t, "cancelled READ", cqe, -int32(zcall.ECANCELED))
+		case 3:
+			expectRes(t, "ASYNC_CANCEL", cqe, 2)
+		default:
+			t.Fatalf("unexpected completion %+v", cqe)
+		}
+	}
+}
+
+func TestPrepCancelFd(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+	buf := make([]byte, 8)
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, rfd, buf, 0) })
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepCancelFd(sqe, rfd, 0) })
+	r.enter(2)
+	for range 2 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 1:
+			expectRes(t, "cancelled READ", cqe, -int32(zcall.ECANCELED))
+		case 2:
+			expectRes(t, "ASYNC_CANCEL fd", cqe, 0)
+		default:
+			t.Fatalf("unexpected completion %+v", cqe)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+// LinkChain builds a chain of linked SQEs, such as a connect with a
+// deadline or a write followed by an fsync, and explains the completions
+// of the chain once it has run.
+//
+// Begin checks that the queue has room for the whole chain. The chain is
+// kept in one submission only if nothing else takes entries from the
+// queue or flushes it until End. Each Next returns the SQE for the
+// following request; after it has been prepared, the chain sets
+// IOSQE_IO_LINK (or IOSQE_IO_HARDLINK) on all but the last entry and tags
+// entry i with user data base+i. Timeout attaches an IORING_OP_LINK_TIMEOUT
+// to the preceding entry.
+//
+// When a linked request fails, the kernel completes the rest of the chain
+// with ECANCELED; a short read or write breaks the chain the same way.
+// Complete collects the completions and Err reports the entry that broke
+// the chain rather than the cascade behind it.
+//
+// A LinkChain can be reused for a new chain once the previous one is done.
+// It must be used by a single goroutine at a time.
+type LinkChain struct {
+	sq       *SubmissionQueue
+	base     uint64
+	link     uint8
+	reserved uint32
+	prev     *IoUringSqe
+	timeout  []bool
+	res      []int32
+	seen     []bool
+	pending  int
+	ended    bool
+}
+
+// Begin starts a chain of up to n entries, link timeouts included, on sq.
+// The entries carry the user data base, base+1, and so on. With hard, the
+// entries are joined with IOSQE_IO_HARDLINK so that a failure does not
+// cancel the rest of the chain. It reports false, leaving the queue
+// untouched, if sq has fewer than n free entries.
+//
+// Begin does not hold the entries: until End, sq must not be used for
+// other NextSQE or Flush calls, or the chain may run out of entries or be
+// submitted in pieces.
+func (c *LinkChain) Begin(sq *SubmissionQueue, base uint64, n uint32, hard bool) bool {
+	if n == 0 || sq.SQSpaceLeft() < n {
+		return false
+	}
+	c.sq, c.base, c.reserved, c.prev = sq, base, n, nil
+	c.link = IOSQE_IO_LINK
+	if hard {
+		c.link = IOSQE_IO_HARDLINK
+	}
+	c.timeout, c.res, c.seen = c.timeout[:0], c.res[:0], c.seen[:0]
+	c.pending, c.ended = 0, false
+	return true
+}
+
+// Next returns the SQE for the next request of the chain, to be prepared
+// by the caller, or nil if the reserved entries are used up.
+func (c *LinkChain) Next() *IoUringSqe {
+	return c.next(false)
+}
+
+// Timeout attaches a link timeout to the preceding request: if ts elapses
+// first, the request is cancelled and completes with ECANCELED. ts is
+// relative unless flags include IORING_TIMEOUT_ABS, and must stay valid
+// until the chain is submitted. It reports false if there is no preceding
+// request or no reserved entry left.
+func (c *LinkChain) Timeout(ts *Timespec, flags uint32) bool {
+	if c.prev == nil || c.timeout[len(c.timeout)-1] {
+		return false
+	}
+	sqe := c.next(true)
+	if sqe == nil {
+		return false
+	}
+	PrepLinkTimeout(sqe, ts, flags)
+	return true
+}
+
+func (c *LinkChain) next(timeout bool) *IoUringSqe {
+	if uint32(len(c.res)) >= c.reserved {
+		return nil
+	}
+	sqe := c.sq.NextSQE()
+	if sqe == nil {
+		return nil
+	}
+	if c.prev != nil {
+		c.finish(len(c.res)-1, c.link)
+	}
+	c.prev = sqe
+	c.timeout = append(c.timeout, timeout)
+	c.res = append(c.res, 0)
+	c.seen = append(c.seen, false)
+	return sqe
+}
+
+// finish tags the prepared entry i with its user data and link flag.
+func (c *LinkChain) finish(i int, link uint8) {
+	c.prev.UserData = c.base + uint64(i)
+	c.prev.Flags |= link
+}
+
+// End completes the chain after the last entry has been prepared and
+// returns the number of entries. The entries are published by the next
+// Flush or Submit of the queue.
+func (c *LinkChain) End() int {
+	if c.prev != nil {
+		c.finish(len(c.res)-1, 0)
+		c.prev = nil
+	}
+	c.pending, c.ended = len(c.res), true
+	return c.pending
+}
+
+// Complete records cqe if it belongs to the chain and reports whether it
+// did. Completions are only accepted once End has run.
+func (c *LinkChain) Complete(cqe *IoUringCqe) bool {
+	i := cqe.UserData - c.base
+	if !c.ended || cqe.UserData < c.base || i >= uint64(len(c.res)) || c.seen[i] {
+		return false
+	}
+	c.res[i] = cqe.Res
+	c.seen[i] = true
+	c.pending--
+	return true
+}
+
+// Done reports whether End has run and every entry of the chain has
+// completed.
+func (c *LinkChain) Done() bool {
+	return c.ended && c.pending == 0
+}
+
+// Len returns the number of entries in the chain.
+func (c *LinkChain) Len() int {
+	return len(c.res)
+}
+
+// Res returns the completion result of entry i.
+func (c *LinkChain) Res(i int) int32 {
+	return c.res[i]
+}
+
+// Err returns the entry that broke the chain and why, or -1 and 0 if every
+// request succeeded. errno is ETIME when the link timeout of the entry
+// expired, and ECANCELED when the entry was cancelled without failing
+// itself, usually because a short transfer before it broke the chain. The
+// completions of link timeouts themselves are not reported.
+func (c *LinkChain) Err() (index int, errno uintptr) {
+	for i, res := range c.res {
+		if c.timeout[i] {
+			continue
+		}
+		if i+1 < len(c.res) && c.timeout[i+1] && c.res[i+1] == -int32(ETIME) {
+			return i, uintptr(ETIME)
+		}
+		if res < 0 {
+			return i, uintptr(-res)
+		}
+	}
+	return -1, 0
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"time"
+
+	"code.hybscloud.com/zcall"
+)
+
+// runChain submits the chain and collects its completions.
+func runChain(t *testing.T, r *testRing, c *zcall.LinkChain) {
+	t.Helper()
+	toSubmit := uintptr(r.sq.Flush())
+	for !c.Done() {
+		cqe, ok := r.pop()
+		if ok {
+			if !c.Complete(&cqe) {
+				t.Fatalf("completion outside the chain: %+v", cqe)
+			}
+			continue
+		}
+		_, errno := retryEINTR(func() (uintptr, uintptr) {
+			return zcall.IoUringEnter(r.fd, toSubmit, 1, zcall.IORING_ENTER_GETEVENTS, nil, 0)
+		})
+		if errno != 0 {
+			t.Fatalf("IoUringEnter failed: %v", zcall.Errno(errno))
+		}
+		toSubmit = 0
+	}
+}
+
+func TestLinkChainWriteFsync(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	f, fd := testFile(t)
+	var c zcall.LinkChain
+	if !c.Begin(r.sq, 100, 2, false) {
+		t.Fatal("Begin failed")
+	}
+	zcall.PrepWrite(c.Next(), fd, []byte("durable"), 0)
+	zcall.PrepFsync(c.Next(), fd, 0)
+	if c.Next() != nil {
+		t.Fatal("Next handed out more entries than reserved")
+	}
+	if n := c.End(); n != 2 {
+		t.Fatalf("End = %d, want 2", n)
+	}
+	runChain(t, r, &c)
+	if i, errno := c.Err(); i != -1 || errno != 0 {
+		t.Fatalf("Err = %d, %v; want none", i, zcall.Errno(errno))
+	}
+	if c.Res(0) != 7 || c.Res(1) != 0 {
+		t.Fatalf("results = %d, %d", c.Res(0), c.Res(1))
+	}
+	buf := make([]byte, 8)
+	if n, _ := f.ReadAt(buf, 0); string(buf[:n]) != "durable" {
+		t.Fatalf("file holds %q", buf[:n])
+	}
+}
+
+func TestLinkChainFailureCascade(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	var c zcall.LinkChain
+	c.Begin(r.sq, 0, 3, false)
+	zcall.PrepNop(c.Next())
+	zcall.PrepRead(c.Next(), -1, make([]byte, 1), 0)
+	zcall.PrepNop(c.Next())
+	c.End()
+	runChain(t, r, &c)
+	if i, errno := c.Err(); i != 1 || zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("Err = %d, %v; want 1, EBADF", i, zcall.Errno(errno))
+	}
+	if c.Res(2) != -int32(zcall.ECANCELED) {
+		t.Fatalf("entry after the failure = %d, want ECANCELED", c.Res(2))
+	}
+
+	// A hard link keeps the chain going past the failure.
+	c.Begin(r.sq, 10, 2, true)
+	zcall.PrepRead(c.Next(), -1, make([]byte, 1), 0)
+	zcall.PrepNop(c.Next())
+	c.End()
+	runChain(t, r, &c)
+	if i, errno := c.Err(); i != 0 || zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("hard chain Err = %d, %v; want 0, EBADF", i, zcall.Errno(errno))
+	}
+	if c.Res(1) != 0 {
+		t.Fatalf("entry after the hard link = %d, want 0", c.Res(1))
+	}
+}
+
+func TestLinkChainShortRead(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, wfd := testPipe(t)
+	zcall.Write(uintptr(wfd), []byte("abc"))
+	var c zcall.LinkChain
+	c.Begin(r.sq, 0, 2, false)
+	zcall.PrepRead(c.Next(), rfd, make([]byte, 8), 0)
+	zcall.PrepNop(c.Next())
+	c.End()
+	runChain(t, r, &c)
+	if c.Res(0) != 3 {
+		t.Fatalf("short read = %d, want 3", c.Res(0))
+	}
+	if i, errno := c.Err(); i != 1 || zcall.Errno(errno) != zcall.ECANCELED {
+		t.Fatalf("Err = %d, %v; want 1, ECANCELED", i, zcall.Errno(errno))
+	}
+}
+
+func TestLinkChainTimeout(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+	var c zcall.LinkChain
+	if c.Timeout(&zcall.Timespec{}, 0) {
+		t.Fatal("Timeout accepted without a preceding request")
+	}
+
+	// A read that never completes is cut off by its deadline, and the
+	// request linked after the timeout is cancelled with it.
+	ts := zcall.Timespec{Nsec: int64(10 * time.Millisecond)}
+	c.Begin(r.sq, 0, 3, false)
+	zcall.PrepRead(c.Next(), rfd, make([]byte, 8), 0)
+	if !c.Timeout(&ts, 0) {
+		t.Fatal("Timeout failed")
+	}
+	if c.Timeout(&ts, 0) {
+		t.Fatal("Timeout accepted a second timeout for the same request")
+	}
+	zcall.PrepNop(c.Next())
+	c.End()
+	runChain(t, r, &c)
+	if i, errno := c.Err(); i != 0 || zcall.Errno(errno) != zcall.ETIME {
+		t.Fatalf("Err = %d, %v; want 0, ETIME", i, zcall.Errno(errno))
+	}
+	if c.Res(0) != -int32(zcall.ECANCELED) || c.Res(1) != -int32(zcall.ETIME) || c.Res(2) != -int32(zcall.ECANCELED) {
+		t.Fatalf("results = %d, %d, %d", c.Res(0), c.Res(1), c.Res(2))
+	}
+
+	// A request that beats its deadline cancels the timeout.
+	c.Begin(r.sq, 0, 2, false)
+	zcall.PrepNop(c.Next())
+	c.Timeout(&zcall.Timespec{Sec: 5}, 0)
+	c.End()
+	runChain(t, r, &c)
+	if i, errno := c.Err(); i != -1 || errno != 0 {
+		t.Fatalf("Err = %d, %v; want none", i, zcall.Errno(errno))
+	}
+	if c.Res(1) != -int32(zcall.ECANCELED) {
+		t.Fatalf("link timeout result = %d, want ECANCELED", c.Res(1))
+	}
+}
+
+func TestLinkChainBeforeEnd(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	var c zcall.LinkChain
+	if !c.Begin(r.sq, 10, 2, false) {
+		t.Fatal("Begin failed")
+	}
+	zcall.PrepNop(c.Next())
+	if c.Done() {
+		t.Fatal("Done before End")
+	}
+	if c.Complete(&zcall.IoUringCqe{UserData: 10}) {
+		t.Fatal("Complete accepted a completion before End")
+	}
+	zcall.PrepNop(c.Next())
+	c.End()
+	if c.Done() {
+		t.Fatal("Done before the chain completed")
+	}
+	runChain(t, r, &c)
+	if !c.Done() || c.Res(0) != 0 || c.Res(1) != 0 {
+		t.Fatalf("done = %v, results = %d, %d", c.Done(), c.Res(0), c.Res(1))
+	}
+}
+
+func TestLinkChainReservation(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	var c zcall.LinkChain
+	if c.Begin(r.sq, 0, 5, false) {
+		t.Fatal("Begin reserved more entries than the queue holds")
+	}
+	r.push(9, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if c.Begin(r.sq, 0, 4, false) {
+		t.Fatal("Begin reserved entries already in use")
+	}
+	if !c.Begin(r.sq, 0, 3, false) {
+		t.Fatal("Begin failed")
+	}
+}
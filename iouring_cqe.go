@@ -0,0 +1,61 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+// CQEResult is a completion decoded from an IoUringCqe.
+type CQEResult struct {
+	UserData uint64
+
+	// N is the non-negative result: a byte count, a file descriptor, or a
+	// registered file slot, depending on the opcode. It is zero on error.
+	N int32
+	// Errno is the error of a failed request, or 0.
+	Errno uintptr
+
+	// BufferID is the provided buffer the request consumed; valid only
+	// when HasBuffer is set (IORING_CQE_F_BUFFER).
+	BufferID  uint16
+	HasBuffer bool
+
+	// More is set while a multishot request stays armed and will post
+	// further completions (IORING_CQE_F_MORE).
+	More bool
+	// SockNonempty is set when the socket still had data queued after a
+	// receive (IORING_CQE_F_SOCK_NONEMPTY).
+	SockNonempty bool
+	// Notif marks the notification completion of a zero-copy send
+	// (IORING_CQE_F_NOTIF).
+	Notif bool
+	// BufMore is set when an incrementally consumed provided buffer
+	// remains in use (IORING_CQE_F_BUF_MORE).
+	BufMore bool
+	// Skip marks a filler entry to be ignored (IORING_CQE_F_SKIP).
+	Skip bool
+	// Big marks a 32-byte entry in a ring with mixed CQE sizes
+	// (IORING_CQE_F_32).
+	Big bool
+}
+
+// Decode returns the typed form of cqe.
+func (cqe *IoUringCqe) Decode() CQEResult {
+	r := CQEResult{
+		UserData:     cqe.UserData,
+		More:         cqe.Flags&IORING_CQE_F_MORE != 0,
+		SockNonempty: cqe.Flags&IORING_CQE_F_SOCK_NONEMPTY != 0,
+		Notif:        cqe.Flags&IORING_CQE_F_NOTIF != 0,
+		BufMore:      cqe.Flags&IORING_CQE_F_BUF_MORE != 0,
+		Skip:         cqe.Flags&IORING_CQE_F_SKIP != 0,
+		Big:          cqe.Flags&IORING_CQE_F_32 != 0,
+	}
+	if cqe.Res < 0 {
+		r.Errno = uintptr(-cqe.Res)
+	} else {
+		r.N = cqe.Res
+	}
+	r.BufferID, r.HasBuffer = cqe.BufferID()
+	return r
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestIoUringCqeDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		cqe  zcall.IoUringCqe
+		want zcall.CQEResult
+	}{
+		{
+			name: "plain",
+			cqe:  zcall.IoUringCqe{UserData: 1, Res: 42},
+			want: zcall.CQEResult{UserData: 1, N: 42},
+		},
+		{
+			name: "error",
+			cqe:  zcall.IoUringCqe{UserData: 2, Res: -int32(zcall.ECANCELED)},
+			want: zcall.CQEResult{UserData: 2, Errno: uintptr(zcall.ECANCELED)},
+		},
+		{
+			name: "multishot_recv",
+			cqe: zcall.IoUringCqe{UserData: 3, Res: 5, Flags: 9<<zcall.IORING_CQE_BUFFER_SHIFT |
+				zcall.IORING_CQE_F_BUFFER | zcall.IORING_CQE_F_MORE | zcall.IORING_CQE_F_SOCK_NONEMPTY},
+			want: zcall.CQEResult{UserData: 3, N: 5, BufferID: 9, HasBuffer: true, More: true, SockNonempty: true},
+		},
+		{
+			name: "notif_and_misc",
+			cqe: zcall.IoUringCqe{Flags: zcall.IORING_CQE_F_NOTIF | zcall.IORING_CQE_F_BUF_MORE |
+				zcall.IORING_CQE_F_SKIP | zcall.IORING_CQE_F_32},
+			want: zcall.CQEResult{Notif: true, BufMore: true, Skip: true, Big: true},
+		},
+		{
+			name: "buffer_id_without_flag",
+			cqe:  zcall.IoUringCqe{Flags: 9 << zcall.IORING_CQE_BUFFER_SHIFT},
+			want: zcall.CQEResult{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cqe.Decode(); got != tt.want {
+				t.Fatalf("Decode = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecvMultishot(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testSocketpair(t)
+	const bgid, bufSize = 1, 64
+	br := newTestBufRing(t, r.fd, 4, bgid, 0)
+	area := testBufArea(t, 4*bufSize)
+	for i := range uint16(4) {
+		br.Add(unsafe.Pointer(&area[int(i)*bufSize]), bufSize, i, i)
+	}
+	br.Advance(4)
+
+	r.push(9, func(sqe *zcall.IoUringSqe) { zcall.PrepRecvMultishot(sqe, b, 0, bgid) })
+	for i, msg := range []string{"one", "three"} {
+		zcall.Write(uintptr(a), []byte(msg))
+		r.enter(1)
+		cqe, ok := r.pop()
+		if !ok {
+			t.Fatal("no completion")
+		}
+		skipIfUnsupported(t, "RECV multishot", cqe)
+		res := cqe.Decode()
+		if res.UserData != 9 || res.Errno != 0 || res.N != int32(len(msg)) {
+			t.Fatalf("completion %d = %+v", i, res)
+		}
+		if !res.More || !res.HasBuffer || res.BufferID != uint16(i) {
+			t.Fatalf("completion %d = %+v, want More with buffer %d", i, res, i)
+		}
+		if got := string(area[i*bufSize : i*bufSize+len(msg)]); got != msg {
+			t.Fatalf("buffer %d = %q, want %q", i, got, msg)
+		}
+	}
+
+	// End of stream terminates the request.
+	zcall.Shutdown(uintptr(a), zcall.SHUT_WR)
+	r.enter(1)
+	cqe := r.next()
+	if res := cqe.Decode(); res.More || res.N != 0 || res.Errno != 0 {
+		t.Fatalf("final completion = %+v, want N 0 without More", res)
+	}
+}
+
+func TestAcceptMultishot(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	lfd, errno := zcall.Socket(zcall.AF_INET, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0)
+	if errno != 0 {
+		t.Fatalf("Socket failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Close(lfd)
+	addr := [16]byte{2, 0, 0, 0, 127, 0, 0, 1}
+	addrLen := uint32(16)
+	if errno := zcall.Bind(lfd, unsafe.Pointer(&addr), 16); errno != 0 {
+		t.Fatalf("Bind failed: %v", zcall.Errno(errno))
+	}
+	zcall.Listen(lfd, 4)
+	zcall.Getsockname(lfd, unsafe.Pointer(&addr), unsafe.Pointer(&addrLen))
+
+	r.push(5, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepAcceptMultishot(sqe, int32(lfd), nil, nil, zcall.SOCK_CLOEXEC)
+	})
+	r.enter(0)
+	for i := range 2 {
+		cfd, errno := zcall.Socket(zcall.AF_INET, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0)
+		if errno != 0 {
+			t.Fatalf("Socket failed: %v", zcall.Errno(errno))
+		}
+		defer zcall.Close(cfd)
+		if errno := zcall.Connect(cfd, unsafe.Pointer(&addr), 16); errno != 0 {
+			t.Fatalf("Connect failed: %v", zcall.Errno(errno))
+		}
+		r.enter(1)
+		cqe := r.next()
+		skipIfUnsupported(t, "ACCEPT multishot", cqe)
+		res := cqe.Decode()
+		if res.UserData != 5 || res.Errno != 0 || !res.More {
+			t.Fatalf("accept %d = %+v, want a descriptor with More", i, res)
+		}
+		zcall.Close(uintptr(res.N))
+	}
+}
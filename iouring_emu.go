@@ -0,0 +1,577 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import (
+	"slices"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Layout of the emulated ring header. Heads and tails sit on cache lines
+// of their own, as in the kernel struct io_rings; the CQE array follows
+// and the SQ index array comes last.
+const (
+	emuSqHead        = 0
+	emuSqTail        = 64
+	emuCqHead        = 128
+	emuCqTail        = 192
+	emuSqRingMask    = 256
+	emuCqRingMask    = 260
+	emuSqRingEntries = 264
+	emuCqRingEntries = 268
+	emuSqDropped     = 272
+	emuSqFlags       = 276
+	emuCqFlags       = 280
+	emuCqOverflow    = 284
+	emuCqes          = 320
+)
+
+// emuSetupFlags are the IORING_SETUP_* flags an Emulator accepts. The
+// others need kernel threads, registered resources, or polled I/O.
+const emuSetupFlags = IORING_SETUP_CQSIZE | IORING_SETUP_CLAMP |
+	IORING_SETUP_SUBMIT_ALL | IORING_SETUP_COOP_TASKRUN |
+	IORING_SETUP_TASKRUN_FLAG | IORING_SETUP_SQE128 | IORING_SETUP_CQE32 |
+	IORING_SETUP_SINGLE_ISSUER | IORING_SETUP_DEFER_TASKRUN |
+	IORING_SETUP_NO_SQARRAY
+
+// EmulatorHooks let tests steer an Emulator. Nil hooks are skipped. The
+// entries passed to the hooks are copies; changing them changes what the
+// Emulator runs.
+type EmulatorHooks struct {
+	// Inject is called before an entry runs. When it returns ok, the entry
+	// does not run and completes with res, such as -EIO.
+	Inject func(sqe *IoUringSqe) (res int32, ok bool)
+
+	// Delay returns the number of later Enter calls an entry is held back
+	// for before it runs, or 0 to run it at once. The entries linked after
+	// it wait with it. An Enter that waits for more completions than are
+	// available releases held entries early, in the order they are due.
+	Delay func(sqe *IoUringSqe) int
+
+	// Reorder may permute the completions an Enter call is about to post.
+	Reorder func(cqes []IoUringCqe)
+}
+
+// Emulator is an in-process stand-in for an io_uring instance, for testing
+// ring consumers where io_uring is unavailable. Its rings have the memory
+// layout of a kernel ring and work with SubmissionQueue and
+// CompletionQueue. Enter takes the place of IoUringEnter: it runs the
+// submitted entries synchronously through the wrappers of this package and
+// posts their completions.
+//
+// The Emulator runs NOP, READ, WRITE, READV, WRITEV, SEND, RECV, SENDMSG,
+// RECVMSG, ACCEPT, CONNECT, SHUTDOWN, SOCKET, CLOSE, SPLICE, and TEE.
+// Entries linked with IOSQE_IO_LINK or IOSQE_IO_HARDLINK and
+// IOSQE_CQE_SKIP_SUCCESS behave as in the kernel. Since every entry has
+// completed by the time Enter returns, a TIMEOUT expires at once with
+// -ETIME, a LINK_TIMEOUT completes with -ECANCELED, and ASYNC_CANCEL,
+// POLL_REMOVE, and TIMEOUT_REMOVE only find entries held back by
+// Hooks.Delay. Of a held back chain they only find the first entry, which
+// takes the rest of the chain with it; the entries linked behind it have
+// not been issued yet, so cancelling one of them completes with -ENOENT.
+// Registered files and buffers, multishot requests, and other opcodes
+// complete with -EINVAL.
+//
+// Entries run on the calling thread, so a read from an empty blocking file
+// blocks Enter; use non-blocking files to get -EAGAIN instead. An Emulator
+// must be used by a single goroutine at a time.
+type Emulator struct {
+	// Hooks steer the emulation. They may be changed between Enter calls.
+	Hooks EmulatorHooks
+
+	sq        IoUringSQ
+	cq        IoUringCQ
+	sqMask    uint32
+	sqEntries uint32
+	cqMask    uint32
+	cqEntries uint32
+	sqeShift  uint
+	cqeShift  uint
+
+	sqes     []IoUringSqe // entries consumed by the current Enter
+	batch    []IoUringCqe // completions of the current Enter
+	overflow []IoUringCqe // completions waiting for room in the CQ
+	pending  []emuChain   // entries held back by Hooks.Delay
+}
+
+// emuChain is a run of linked entries held back by Hooks.Delay. The first
+// entry has already been passed to the hook.
+type emuChain struct {
+	ops   []IoUringSqe
+	ticks int
+}
+
+// NewEmulator creates an Emulator with room for entries submission queue
+// entries. p is used as for IoUringSetup: the caller sets Flags, and
+// CqEntries with IORING_SETUP_CQSIZE; NewEmulator fills in the entry
+// counts, features, and ring offsets. The rings live in one anonymous
+// mapping released with r.Unmap once the Emulator is no longer used.
+func NewEmulator(entries uint32, p *IoUringParams) (e *Emulator, r IoUringRings, errno uintptr) {
+	if entries == 0 || p.Flags&^emuSetupFlags != 0 {
+		return nil, IoUringRings{}, uintptr(EINVAL)
+	}
+	if p.Flags&IORING_SETUP_DEFER_TASKRUN != 0 && p.Flags&IORING_SETUP_SINGLE_ISSUER == 0 {
+		return nil, IoUringRings{}, uintptr(EINVAL)
+	}
+	clamp := p.Flags&IORING_SETUP_CLAMP != 0
+	if entries > 32768 {
+		if !clamp {
+			return nil, IoUringRings{}, uintptr(EINVAL)
+		}
+		entries = 32768
+	}
+	sqEntries := roundPow2(entries)
+	cqEntries := 2 * sqEntries
+	if p.Flags&IORING_SETUP_CQSIZE != 0 {
+		n := p.CqEntries
+		if n == 0 || n > 65536 && !clamp {
+			return nil, IoUringRings{}, uintptr(EINVAL)
+		}
+		cqEntries = roundPow2(min(n, 65536))
+		if cqEntries < sqEntries {
+			return nil, IoUringRings{}, uintptr(EINVAL)
+		}
+	}
+
+	r.SetupFlags = p.Flags
+	r.SQESize = unsafe.Sizeof(IoUringSqe{})
+	if p.Flags&IORING_SETUP_SQE128 != 0 {
+		r.SQESize *= 2
+	}
+	r.CQESize = unsafe.Sizeof(IoUringCqe{})
+	if p.Flags&IORING_SETUP_CQE32 != 0 {
+		r.CQESize *= 2
+	}
+	arrayOff := emuCqes + uintptr(cqEntries)*r.CQESize
+	ringSize := arrayOff
+	if p.Flags&IORING_SETUP_NO_SQARRAY == 0 {
+		ringSize += uintptr(sqEntries) * 4
+	}
+	sqesSize := uintptr(sqEntries) * r.SQESize
+	sqesSize = (sqesSize + maxPageSize - 1) &^ (maxPageSize - 1)
+	ringSize = (ringSize + maxPageSize - 1) &^ (maxPageSize - 1)
+	size := sqesSize + ringSize
+	mem, errno := Mmap(nil, size, PROT_READ|PROT_WRITE, MAP_PRIVATE|MAP_ANONYMOUS, ^uintptr(0), 0)
+	if errno != 0 {
+		return nil, IoUringRings{}, errno
+	}
+	rings := unsafe.Add(mem, sqesSize)
+
+	p.SqEntries = sqEntries
+	p.CqEntries = cqEntries
+	p.Features = IORING_FEAT_SINGLE_MMAP | IORING_FEAT_NODROP |
+		IORING_FEAT_SUBMIT_STABLE | IORING_FEAT_RW_CUR_POS |
+		IORING_FEAT_EXT_ARG | IORING_FEAT_CQE_SKIP
+	p.SqOff = IoSqringOffsets{
+		Head:        emuSqHead,
+		Tail:        emuSqTail,
+		RingMask:    emuSqRingMask,
+		RingEntries: emuSqRingEntries,
+		Flags:       emuSqFlags,
+		Dropped:     emuSqDropped,
+		Array:       uint32(arrayOff),
+	}
+	p.CqOff = IoCqringOffsets{
+		Head:        emuCqHead,
+		Tail:        emuCqTail,
+		RingMask:    emuCqRingMask,
+		RingEntries: emuCqRingEntries,
+		Overflow:    emuCqOverflow,
+		Cqes:        emuCqes,
+		Flags:       emuCqFlags,
+	}
+	*(*uint32)(unsafe.Add(rings, emuSqRingMask)) = sqEntries - 1
+	*(*uint32)(unsafe.Add(rings, emuSqRingEntries)) = sqEntries
+	*(*uint32)(unsafe.Add(rings, emuCqRingMask)) = cqEntries - 1
+	*(*uint32)(unsafe.Add(rings, emuCqRingEntries)) = cqEntries
+
+	// One mapping backs everything; Unmap releases it through sqes.
+	r.sqes, r.sqesSize = mem, size
+	r.sqRing, r.cqRing = rings, rings
+	r.initViews(p)
+
+	e = &Emulator{
+		sq:        r.SQ,
+		cq:        r.CQ,
+		sqMask:    sqEntries - 1,
+		sqEntries: sqEntries,
+		cqMask:    cqEntries - 1,
+		cqEntries: cqEntries,
+	}
+	if r.SQESize > unsafe.Sizeof(IoUringSqe{}) {
+		e.sqeShift = 1
+	}
+	if r.CQESize > unsafe.Sizeof(IoUringCqe{}) {
+		e.cqeShift = 1
+	}
+	return e, r, 0
+}
+
+// Enter is the IoUringEnter of the Emulator. It consumes up to toSubmit
+// entries from the submission queue and runs them. With
+// IORING_ENTER_GETEVENTS, it then makes minComplete completions available
+// if it can: entries held back by Hooks.Delay are released early, and
+// since nothing else can complete, a wait that still falls short fails at
+// once with ETIME if arg carries a timeout (IORING_ENTER_EXT_ARG) and
+// EAGAIN otherwise. As with the kernel, a wait error is only reported when
+// no entry was submitted. Completions that do not fit in the completion
+// queue are kept and IORING_SQ_CQ_OVERFLOW is raised until they are
+// posted by a later Enter. Other flags are ignored.
+//
+// It returns the number of entries submitted.
+func (e *Emulator) Enter(toSubmit, minComplete, flags uintptr, arg unsafe.Pointer, argSize uintptr) (r1 uintptr, errno uintptr) {
+	e.flushOverflow()
+	e.tick(1)
+	submitted := e.submit(toSubmit)
+	if flags&IORING_ENTER_GETEVENTS != 0 {
+		for uintptr(e.ready())+uintptr(len(e.batch)) < minComplete && len(e.pending) > 0 {
+			next := e.pending[0].ticks
+			for _, c := range e.pending[1:] {
+				next = min(next, c.ticks)
+			}
+			e.tick(next)
+		}
+	}
+	e.post()
+	if flags&IORING_ENTER_GETEVENTS != 0 && uintptr(e.ready()) < minComplete && submitted == 0 {
+		if flags&IORING_ENTER_EXT_ARG != 0 && arg != nil && (*IoUringGeteventsArg)(arg).Ts != 0 {
+			return 0, uintptr(ETIME)
+		}
+		return 0, uintptr(EAGAIN)
+	}
+	return submitted, 0
+}
+
+// Pending returns the number of entries held back by Hooks.Delay.
+func (e *Emulator) Pending() int {
+	n := 0
+	for _, c := range e.pending {
+		n += len(c.ops)
+	}
+	return n
+}
+
+// submit consumes up to toSubmit entries and runs them chain by chain.
+func (e *Emulator) submit(toSubmit uintptr) uintptr {
+	head := *e.sq.Head
+	n := atomic.LoadUint32(e.sq.Tail) - head
+	if uintptr(n) > toSubmit {
+		n = uint32(toSubmit)
+	}
+	e.sqes = e.sqes[:0]
+	for range n {
+		idx := head & e.sqMask
+		head++
+		if e.sq.Array != nil {
+			idx = *(*uint32)(unsafe.Add(e.sq.Array, uintptr(idx)*4))
+			if idx >= e.sqEntries {
+				atomic.AddUint32(e.sq.Dropped, 1)
+				continue
+			}
+		}
+		e.sqes = append(e.sqes, *(*IoUringSqe)(unsafe.Add(e.sq.SQEs, uintptr(idx)<<(6+e.sqeShift))))
+	}
+	atomic.StoreUint32(e.sq.Head, head)
+
+	for start := 0; start < len(e.sqes); {
+		end := start
+		for end < len(e.sqes)-1 && e.sqes[end].Flags&(IOSQE_IO_LINK|IOSQE_IO_HARDLINK) != 0 {
+			end++
+		}
+		e.runChain(e.sqes[start:end+1], false)
+		start = end + 1
+	}
+	return uintptr(len(e.sqes))
+}
+
+// runChain runs a run of linked entries. A failing entry linked with
+// IOSQE_IO_LINK cancels the rest; as in the kernel, a short transfer
+// counts as a failure. A LINK_TIMEOUT only reports on the entry it
+// guards, so its -ECANCELED does not break the chain, and neither does the
+// -ETIME of a TIMEOUT with IORING_TIMEOUT_ETIME_SUCCESS. resumed is set
+// when the first entry was already passed to Hooks.Delay.
+func (e *Emulator) runChain(ops []IoUringSqe, resumed bool) {
+	failed := false
+	for i := range ops {
+		sqe := &ops[i]
+		if failed {
+			e.complete(sqe, -int32(ECANCELED))
+			continue
+		}
+		if e.Hooks.Delay != nil && (i > 0 || !resumed) {
+			if d := e.Hooks.Delay(sqe); d > 0 {
+				e.pending = append(e.pending, emuChain{ops: slices.Clone(ops[i:]), ticks: d})
+				return
+			}
+		}
+		res := e.run(sqe)
+		e.complete(sqe, res)
+		if sqe.Opcode == IORING_OP_LINK_TIMEOUT {
+			continue
+		}
+		if sqe.Opcode == IORING_OP_TIMEOUT && sqe.OpFlags&IORING_TIMEOUT_ETIME_SUCCESS != 0 && res == -int32(ETIME) {
+			continue
+		}
+		if (res < 0 || short(sqe, res)) && sqe.Flags&IOSQE_IO_HARDLINK == 0 {
+			failed = true
+		}
+	}
+}
+
+// tick advances the held back entries by n Enter calls and runs those that
+// are due, in the order they were held back.
+func (e *Emulator) tick(n int) {
+	var due []emuChain
+	kept := e.pending[:0]
+	for _, c := range e.pending {
+		if c.ticks -= n; c.ticks <= 0 {
+			due = append(due, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	clear(e.pending[len(kept):])
+	e.pending = kept
+	for _, c := range due {
+		e.runChain(c.ops, true)
+	}
+}
+
+// cancel completes the held back chain whose first entry has userData with
+// -ECANCELED.
+func (e *Emulator) cancel(userData uint64) (errno uintptr) {
+	for i, c := range e.pending {
+		if c.ops[0].UserData != userData {
+			continue
+		}
+		e.pending = slices.Delete(e.pending, i, i+1)
+		for j := range c.ops {
+			e.complete(&c.ops[j], -int32(ECANCELED))
+		}
+		return 0
+	}
+	return uintptr(ENOENT)
+}
+
+// run carries out a single entry and returns its result.
+func (e *Emulator) run(sqe *IoUringSqe) int32 {
+	if e.Hooks.Inject != nil {
+		if res, ok := e.Hooks.Inject(sqe); ok {
+			return res
+		}
+	}
+	if sqe.Flags&(IOSQE_FIXED_FILE|IOSQE_BUFFER_SELECT) != 0 {
+		return -int32(EINVAL)
+	}
+	fd := uintptr(sqe.Fd)
+	var n, errno uintptr
+	switch sqe.Opcode {
+	case IORING_OP_NOP:
+	case IORING_OP_READ, IORING_OP_WRITE:
+		iov := Iovec{Base: (*byte)(ptrOf(sqe.Addr)), Len: uint64(sqe.Len)}
+		n, errno = e.rw(sqe, unsafe.Pointer(&iov), 1)
+	case IORING_OP_READV, IORING_OP_WRITEV:
+		n, errno = e.rw(sqe, ptrOf(sqe.Addr), uintptr(sqe.Len))
+	case IORING_OP_SEND:
+		buf, ok := bufOf(sqe)
+		if !ok {
+			return -int32(EFAULT)
+		}
+		n, errno = Sendto(fd, buf, uintptr(sqe.OpFlags), ptrOf(sqe.Off), uintptr(uint16(sqe.FileIndex)))
+	case IORING_OP_RECV:
+		if sqe.Ioprio&IORING_RECV_MULTISHOT != 0 {
+			return -int32(EINVAL)
+		}
+		buf, ok := bufOf(sqe)
+		if !ok {
+			return -int32(EFAULT)
+		}
+		n, errno = Recvfrom(fd, buf, uintptr(sqe.OpFlags), nil, nil)
+	case IORING_OP_SENDMSG:
+		n, errno = Sendmsg(fd, ptrOf(sqe.Addr), uintptr(sqe.OpFlags))
+	case IORING_OP_RECVMSG:
+		if sqe.Ioprio&IORING_RECV_MULTISHOT != 0 {
+			return -int32(EINVAL)
+		}
+		n, errno = Recvmsg(fd, ptrOf(sqe.Addr), uintptr(sqe.OpFlags))
+	case IORING_OP_ACCEPT:
+		if sqe.Ioprio&IORING_ACCEPT_MULTISHOT != 0 || sqe.FileIndex != 0 {
+			return -int32(EINVAL)
+		}
+		n, errno = Accept4(fd, ptrOf(sqe.Addr), ptrOf(sqe.Off), uintptr(sqe.OpFlags))
+	case IORING_OP_CONNECT:
+		errno = Connect(fd, ptrOf(sqe.Addr), uintptr(sqe.Off))
+	case IORING_OP_SHUTDOWN:
+		errno = Shutdown(fd, uintptr(sqe.Len))
+	case IORING_OP_SOCKET:
+		if sqe.FileIndex != 0 {
+			return -int32(EINVAL)
+		}
+		n, errno = Socket(uintptr(sqe.Fd), uintptr(sqe.Off), uintptr(sqe.Len))
+	case IORING_OP_CLOSE:
+		if sqe.FileIndex != 0 {
+			return -int32(EINVAL)
+		}
+		errno = Close(fd)
+	case IORING_OP_SPLICE:
+		if sqe.OpFlags&SPLICE_F_FD_IN_FIXED != 0 {
+			return -int32(EINVAL)
+		}
+		offIn, offOut := int64(sqe.Addr), int64(sqe.Off)
+		var pIn, pOut *int64
+		if offIn != -1 {
+			pIn = &offIn
+		}
+		if offOut != -1 {
+			pOut = &offOut
+		}
+		n, errno = Splice(uintptr(int32(sqe.FileIndex)), pIn, fd, pOut, uintptr(sqe.Len), uintptr(sqe.OpFlags))
+	case IORING_OP_TEE:
+		if sqe.OpFlags&SPLICE_F_FD_IN_FIXED != 0 {
+			return -int32(EINVAL)
+		}
+		n, errno = Tee(uintptr(int32(sqe.FileIndex)), fd, uintptr(sqe.Len), uintptr(sqe.OpFlags))
+	case IORING_OP_TIMEOUT:
+		errno = uintptr(ETIME)
+	case IORING_OP_LINK_TIMEOUT:
+		errno = uintptr(ECANCELED)
+	case IORING_OP_ASYNC_CANCEL:
+		if sqe.OpFlags != 0 {
+			return -int32(EINVAL)
+		}
+		errno = e.cancel(sqe.Addr)
+	case IORING_OP_POLL_REMOVE, IORING_OP_TIMEOUT_REMOVE:
+		errno = e.cancel(sqe.Addr)
+	default:
+		errno = uintptr(EINVAL)
+	}
+	if errno != 0 {
+		return -int32(errno)
+	}
+	return int32(n)
+}
+
+// rw runs a read or write entry. Offsets are ignored on files that cannot
+// seek, as by the kernel, and an offset of -1 uses the file position.
+func (e *Emulator) rw(sqe *IoUringSqe, iov unsafe.Pointer, iovcnt uintptr) (n, errno uintptr) {
+	fd, off, flags := uintptr(sqe.Fd), int64(sqe.Off), uintptr(sqe.OpFlags)
+	read := sqe.Opcode == IORING_OP_READ || sqe.Opcode == IORING_OP_READV
+	if read {
+		n, errno = Preadv2(fd, iov, iovcnt, off, flags)
+	} else {
+		n, errno = Pwritev2(fd, iov, iovcnt, off, flags)
+	}
+	if errno != uintptr(ESPIPE) {
+		return n, errno
+	}
+	if read {
+		return Readv(fd, iov, iovcnt)
+	}
+	return Writev(fd, iov, iovcnt)
+}
+
+// short reports whether the transfer of sqe moved fewer than the requested
+// bytes. Sends and receives are only held to their length with MSG_WAITALL.
+func short(sqe *IoUringSqe, res int32) bool {
+	switch sqe.Opcode {
+	case IORING_OP_READ, IORING_OP_WRITE:
+		return uint32(res) < sqe.Len
+	case IORING_OP_SEND, IORING_OP_RECV:
+		return sqe.OpFlags&MSG_WAITALL != 0 && uint32(res) < sqe.Len
+	case IORING_OP_READV, IORING_OP_WRITEV:
+		return uint64(res) < iovLen((*Iovec)(ptrOf(sqe.Addr)), uint64(sqe.Len))
+	case IORING_OP_SENDMSG, IORING_OP_RECVMSG:
+		if sqe.OpFlags&MSG_WAITALL == 0 {
+			return false
+		}
+		msg := (*Msghdr)(ptrOf(sqe.Addr))
+		return uint64(res) < iovLen(msg.Iov, msg.Iovlen)
+	}
+	return false
+}
+
+// iovLen returns the total length of the n buffers of iov.
+func iovLen(iov *Iovec, n uint64) (total uint64) {
+	for _, v := range unsafe.Slice(iov, n) {
+		total += v.Len
+	}
+	return total
+}
+
+// complete records the completion of sqe unless IOSQE_CQE_SKIP_SUCCESS
+// suppresses it.
+func (e *Emulator) complete(sqe *IoUringSqe, res int32) {
+	if res >= 0 && sqe.Flags&IOSQE_CQE_SKIP_SUCCESS != 0 {
+		return
+	}
+	e.batch = append(e.batch, IoUringCqe{UserData: sqe.UserData, Res: res})
+}
+
+// post hands the completions of the current Enter to Hooks.Reorder and
+// posts them.
+func (e *Emulator) post() {
+	if e.Hooks.Reorder != nil && len(e.batch) > 1 {
+		e.Hooks.Reorder(e.batch)
+	}
+	for _, c := range e.batch {
+		if len(e.overflow) > 0 || !e.push(c) {
+			e.overflow = append(e.overflow, c)
+			atomic.OrUint32(e.sq.Flags, IORING_SQ_CQ_OVERFLOW)
+		}
+	}
+	e.batch = e.batch[:0]
+}
+
+// flushOverflow posts the kept completions that fit in the CQ.
+func (e *Emulator) flushOverflow() {
+	i := 0
+	for i < len(e.overflow) && e.push(e.overflow[i]) {
+		i++
+	}
+	e.overflow = slices.Delete(e.overflow, 0, i)
+	if len(e.overflow) == 0 {
+		atomic.AndUint32(e.sq.Flags, ^uint32(IORING_SQ_CQ_OVERFLOW))
+	}
+}
+
+// push writes c to the CQ, reporting false if the CQ is full.
+func (e *Emulator) push(c IoUringCqe) bool {
+	tail := *e.cq.Tail
+	if tail-atomic.LoadUint32(e.cq.Head) >= e.cqEntries {
+		return false
+	}
+	dst := unsafe.Add(e.cq.CQEs, uintptr(tail&e.cqMask)<<(4+e.cqeShift))
+	if e.cqeShift != 0 {
+		*(*IoUringCqe32)(dst) = IoUringCqe32{IoUringCqe: c}
+	} else {
+		*(*IoUringCqe)(dst) = c
+	}
+	atomic.StoreUint32(e.cq.Tail, tail+1)
+	return true
+}
+
+// ready returns the number of completions posted and not yet consumed.
+func (e *Emulator) ready() uint32 {
+	return atomic.LoadUint32(e.cq.Tail) - atomic.LoadUint32(e.cq.Head)
+}
+
+// ptrOf returns the address held in an SQE field as a pointer.
+func ptrOf(addr uint64) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&addr))
+}
+
+// bufOf returns the buffer addressed by sqe.Addr and sqe.Len.
+func bufOf(sqe *IoUringSqe) (buf []byte, ok bool) {
+	if sqe.Len == 0 {
+		return nil, true
+	}
+	if sqe.Addr == 0 {
+		return nil, false
+	}
+	return unsafe.Slice((*byte)(ptrOf(sqe.Addr)), sqe.Len), true
+}
@@ -0,0 +1,437 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// emuRing is a testRing driven by an Emulator instead of a kernel ring.
+type emuRing struct {
+	t     *testing.T
+	e     *zcall.Emulator
+	rings zcall.IoUringRings
+	sq    *zcall.SubmissionQueue
+	cq    *zcall.CompletionQueue
+}
+
+func newEmuRing(t *testing.T, entries uint32, p *zcall.IoUringParams) *emuRing {
+	t.Helper()
+	e, rings, errno := zcall.NewEmulator(entries, p)
+	if errno != 0 {
+		t.Fatalf("NewEmulator failed: %v", zcall.Errno(errno))
+	}
+	r := &emuRing{t: t, e: e, rings: rings}
+	r.sq = zcall.NewSubmissionQueue(&r.rings)
+	r.cq = zcall.NewCompletionQueue(&r.rings)
+	t.Cleanup(func() { r.rings.Unmap() })
+	return r
+}
+
+// push prepares the next SQE with prep and tags it with userData.
+func (r *emuRing) push(userData uint64, prep func(sqe *zcall.IoUringSqe)) {
+	r.t.Helper()
+	sqe := r.sq.NextSQE()
+	if sqe == nil {
+		r.t.Fatal("submission queue full")
+	}
+	prep(sqe)
+	sqe.UserData = userData
+}
+
+// enter flushes pending SQEs and waits for minComplete completions.
+func (r *emuRing) enter(minComplete uintptr) {
+	r.t.Helper()
+	toSubmit := r.sq.Flush()
+	if _, errno := r.e.Enter(uintptr(toSubmit), minComplete, zcall.IORING_ENTER_GETEVENTS, nil, 0); errno != 0 {
+		r.t.Fatalf("Enter failed: %v", zcall.Errno(errno))
+	}
+}
+
+// drain removes every available CQE.
+func (r *emuRing) drain() []zcall.IoUringCqe {
+	var cqes []zcall.IoUringCqe
+	for c := r.cq.PeekCQE(); c != nil; c = r.cq.PeekCQE() {
+		cqes = append(cqes, *c)
+		r.cq.SeenCQE()
+	}
+	return cqes
+}
+
+// expectCQEs fails the test unless got holds the user data and results in
+// want, in order.
+func expectCQEs(t *testing.T, got []zcall.IoUringCqe, want ...[2]int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d completions %+v, want %d", len(got), got, len(want))
+	}
+	for i, c := range got {
+		if int64(c.UserData) != want[i][0] || int64(c.Res) != want[i][1] {
+			t.Fatalf("completion %d = {UserData: %d, Res: %d}, want {%d, %d}", i, c.UserData, c.Res, want[i][0], want[i][1])
+		}
+	}
+}
+
+func TestNewEmulator(t *testing.T) {
+	p := zcall.IoUringParams{}
+	r := newEmuRing(t, 3, &p)
+	if p.SqEntries != 4 || p.CqEntries != 8 {
+		t.Fatalf("entries = %d/%d, want 4/8", p.SqEntries, p.CqEntries)
+	}
+	if p.Features&zcall.IORING_FEAT_SINGLE_MMAP == 0 || p.Features&zcall.IORING_FEAT_NODROP == 0 {
+		t.Fatalf("features = %#x", p.Features)
+	}
+	if r.sq.Entries() != 4 || r.cq.Entries() != 8 || r.rings.SQ.Array == nil {
+		t.Fatal("ring views do not match the parameters")
+	}
+
+	p = zcall.IoUringParams{Flags: zcall.IORING_SETUP_CQSIZE | zcall.IORING_SETUP_NO_SQARRAY | zcall.IORING_SETUP_CQE32, CqEntries: 100}
+	r = newEmuRing(t, 8, &p)
+	if p.CqEntries != 128 || r.rings.SQ.Array != nil || r.rings.CQESize != 32 {
+		t.Fatalf("CqEntries = %d, Array = %v, CQESize = %d", p.CqEntries, r.rings.SQ.Array, r.rings.CQESize)
+	}
+	r.push(7, zcall.PrepNop)
+	r.enter(1)
+	expectCQEs(t, r.drain(), [2]int64{7, 0})
+
+	for _, flags := range []uint32{zcall.IORING_SETUP_SQPOLL, zcall.IORING_SETUP_DEFER_TASKRUN} {
+		p := zcall.IoUringParams{Flags: flags}
+		if _, _, errno := zcall.NewEmulator(4, &p); zcall.Errno(errno) != zcall.EINVAL {
+			t.Errorf("flags %#x: got %v, want EINVAL", flags, zcall.Errno(errno))
+		}
+	}
+	if _, _, errno := zcall.NewEmulator(0, &zcall.IoUringParams{}); zcall.Errno(errno) != zcall.EINVAL {
+		t.Errorf("0 entries: got %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+func TestEmulatorReadWrite(t *testing.T) {
+	r := newEmuRing(t, 8, &zcall.IoUringParams{})
+	pr, pw := testPipe(t)
+	f, fd := testFile(t)
+	if _, err := f.WriteString("0123456789"); err != nil {
+		t.Fatal(err)
+	}
+
+	in := []byte("hello")
+	out := make([]byte, 5)
+	fileBuf := make([]byte, 4)
+	r.push(1, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWrite(sqe, pw, in, 0)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, pr, out, 0) })
+	r.push(3, func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, fd, fileBuf, 3) })
+	r.enter(3)
+	expectCQEs(t, r.drain(), [2]int64{1, 5}, [2]int64{2, 5}, [2]int64{3, 4})
+	if string(out) != "hello" || string(fileBuf) != "3456" {
+		t.Fatalf("read %q and %q", out, fileBuf)
+	}
+}
+
+func TestEmulatorSocket(t *testing.T) {
+	r := newEmuRing(t, 8, &zcall.IoUringParams{})
+	a, b := testSocketpair(t)
+	buf := make([]byte, 8)
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepSend(sqe, a, []byte("ping"), 0) })
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepRecv(sqe, b, buf, 0) })
+	r.push(3, func(sqe *zcall.IoUringSqe) { zcall.PrepShutdown(sqe, a, zcall.SHUT_WR) })
+	r.push(4, func(sqe *zcall.IoUringSqe) { zcall.PrepRecv(sqe, b, buf, 0) })
+	r.enter(4)
+	expectCQEs(t, r.drain(), [2]int64{1, 4}, [2]int64{2, 4}, [2]int64{3, 0}, [2]int64{4, 0})
+}
+
+func TestEmulatorLinks(t *testing.T) {
+	r := newEmuRing(t, 8, &zcall.IoUringParams{})
+	buf := make([]byte, 1)
+	r.push(1, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRead(sqe, -1, buf, 0)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(2, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(3, zcall.PrepNop)
+	r.push(4, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRead(sqe, -1, buf, 0)
+		sqe.Flags |= zcall.IOSQE_IO_HARDLINK
+	})
+	r.push(5, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.Flags |= zcall.IOSQE_CQE_SKIP_SUCCESS
+	})
+	r.push(6, zcall.PrepNop)
+	r.enter(4)
+	expectCQEs(t, r.drain(),
+		[2]int64{1, -int64(zcall.EBADF)},
+		[2]int64{2, -int64(zcall.ECANCELED)},
+		[2]int64{3, -int64(zcall.ECANCELED)},
+		[2]int64{4, -int64(zcall.EBADF)},
+		[2]int64{6, 0},
+	)
+
+	// A link timeout reports on the entry it guards and does not break
+	// the chain.
+	ts := zcall.Timespec{Sec: 1}
+	r.push(7, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(8, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepLinkTimeout(sqe, &ts, 0)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(9, zcall.PrepNop)
+	r.enter(3)
+	expectCQEs(t, r.drain(),
+		[2]int64{7, 0},
+		[2]int64{8, -int64(zcall.ECANCELED)},
+		[2]int64{9, 0},
+	)
+
+	// An expired timeout with IORING_TIMEOUT_ETIME_SUCCESS does not fail.
+	r.push(10, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepTimeout(sqe, &ts, 0, zcall.IORING_TIMEOUT_ETIME_SUCCESS)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(11, zcall.PrepNop)
+	r.enter(2)
+	expectCQEs(t, r.drain(),
+		[2]int64{10, -int64(zcall.ETIME)},
+		[2]int64{11, 0},
+	)
+}
+
+func TestEmulatorInject(t *testing.T) {
+	r := newEmuRing(t, 4, &zcall.IoUringParams{})
+	pr, pw := testPipe(t)
+	r.e.Hooks.Inject = func(sqe *zcall.IoUringSqe) (int32, bool) {
+		if sqe.Opcode == zcall.IORING_OP_WRITE {
+			return -int32(zcall.EIO), true
+		}
+		return 0, false
+	}
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepWrite(sqe, pw, []byte("x"), 0) })
+	r.push(2, zcall.PrepNop)
+	r.enter(2)
+	expectCQEs(t, r.drain(), [2]int64{1, -int64(zcall.EIO)}, [2]int64{2, 0})
+
+	// The injected write did not run.
+	zcall.Close(uintptr(pw))
+	if n, errno := zcall.Read(uintptr(pr), make([]byte, 1)); n != 0 || errno != 0 {
+		t.Fatalf("pipe read = %d, %v; want EOF", n, zcall.Errno(errno))
+	}
+}
+
+func TestEmulatorReorder(t *testing.T) {
+	r := newEmuRing(t, 4, &zcall.IoUringParams{})
+	r.e.Hooks.Reorder = slices.Reverse[[]zcall.IoUringCqe]
+	for i := range 3 {
+		r.push(uint64(i+1), zcall.PrepNop)
+	}
+	r.enter(3)
+	expectCQEs(t, r.drain(), [2]int64{3, 0}, [2]int64{2, 0}, [2]int64{1, 0})
+}
+
+func TestEmulatorDelay(t *testing.T) {
+	r := newEmuRing(t, 8, &zcall.IoUringParams{})
+	var fds [2]int32
+	if errno := zcall.Pipe2(&fds, zcall.O_NONBLOCK|zcall.O_CLOEXEC); errno != 0 {
+		t.Fatalf("Pipe2 failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Close(uintptr(fds[0]))
+	defer zcall.Close(uintptr(fds[1]))
+
+	// A delayed read runs after data written in between.
+	r.e.Hooks.Delay = func(sqe *zcall.IoUringSqe) int {
+		if sqe.UserData == 1 {
+			return 2
+		}
+		return 0
+	}
+	buf := make([]byte, 4)
+	r.push(1, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRead(sqe, fds[0], buf, 0)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(2, zcall.PrepNop)
+	r.push(3, zcall.PrepNop)
+	r.enter(0)
+	expectCQEs(t, r.drain(), [2]int64{3, 0})
+	if got := r.e.Pending(); got != 2 {
+		t.Fatalf("Pending = %d, want 2", got)
+	}
+	r.enter(0)
+	expectCQEs(t, r.drain())
+
+	zcall.Write(uintptr(fds[1]), []byte("data"))
+	r.enter(0)
+	expectCQEs(t, r.drain(), [2]int64{1, 4}, [2]int64{2, 0})
+
+	// Waiting releases held back entries early.
+	r.e.Hooks.Delay = func(*zcall.IoUringSqe) int { return 100 }
+	r.push(4, zcall.PrepNop)
+	r.enter(1)
+	expectCQEs(t, r.drain(), [2]int64{4, 0})
+}
+
+func TestEmulatorCancel(t *testing.T) {
+	r := newEmuRing(t, 8, &zcall.IoUringParams{})
+	r.e.Hooks.Delay = func(sqe *zcall.IoUringSqe) int {
+		if sqe.Opcode == zcall.IORING_OP_TIMEOUT {
+			return 100
+		}
+		return 0
+	}
+	ts := zcall.Timespec{Sec: 1}
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepTimeout(sqe, &ts, 0, 0) })
+	r.enter(0)
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepCancel(sqe, 1, 0) })
+	r.push(3, func(sqe *zcall.IoUringSqe) { zcall.PrepCancel(sqe, 1, 0) })
+	r.enter(3)
+	expectCQEs(t, r.drain(),
+		[2]int64{1, -int64(zcall.ECANCELED)},
+		[2]int64{2, 0},
+		[2]int64{3, -int64(zcall.ENOENT)},
+	)
+
+	// Only the first entry of a held back chain is found, and cancelling it
+	// cancels the rest.
+	r.push(4, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepTimeout(sqe, &ts, 0, 0)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(5, zcall.PrepNop)
+	r.enter(0)
+	r.push(6, func(sqe *zcall.IoUringSqe) { zcall.PrepCancel(sqe, 5, 0) })
+	r.push(7, func(sqe *zcall.IoUringSqe) { zcall.PrepCancel(sqe, 4, 0) })
+	r.enter(4)
+	expectCQEs(t, r.drain(),
+		[2]int64{6, -int64(zcall.ENOENT)},
+		[2]int64{4, -int64(zcall.ECANCELED)},
+		[2]int64{5, -int64(zcall.ECANCELED)},
+		[2]int64{7, 0},
+	)
+
+	r.e.Hooks.Delay = nil
+	r.push(8, func(sqe *zcall.IoUringSqe) { zcall.PrepTimeout(sqe, &ts, 0, 0) })
+	r.enter(1)
+	expectCQEs(t, r.drain(), [2]int64{8, -int64(zcall.ETIME)})
+}
+
+func TestEmulatorOverflow(t *testing.T) {
+	r := newEmuRing(t, 2, &zcall.IoUringParams{})
+	for round := range 3 {
+		r.push(uint64(2*round+1), zcall.PrepNop)
+		r.push(uint64(2*round+2), zcall.PrepNop)
+		r.enter(0)
+	}
+	if !r.sq.CQNeedsFlush() {
+		t.Fatal("IORING_SQ_CQ_OVERFLOW not raised")
+	}
+	got := r.drain()
+	r.enter(0)
+	got = append(got, r.drain()...)
+	expectCQEs(t, got,
+		[2]int64{1, 0}, [2]int64{2, 0}, [2]int64{3, 0},
+		[2]int64{4, 0}, [2]int64{5, 0}, [2]int64{6, 0},
+	)
+	if r.sq.CQNeedsFlush() {
+		t.Fatal("IORING_SQ_CQ_OVERFLOW still raised")
+	}
+}
+
+func TestEmulatorWait(t *testing.T) {
+	r := newEmuRing(t, 2, &zcall.IoUringParams{})
+	if _, errno := r.e.Enter(0, 1, zcall.IORING_ENTER_GETEVENTS, nil, 0); zcall.Errno(errno) != zcall.EAGAIN {
+		t.Fatalf("wait without completions: got %v, want EAGAIN", zcall.Errno(errno))
+	}
+	ts := zcall.Timespec{Nsec: 1000}
+	arg := zcall.IoUringGeteventsArg{Ts: uint64(uintptr(unsafe.Pointer(&ts)))}
+	flags := uintptr(zcall.IORING_ENTER_GETEVENTS | zcall.IORING_ENTER_EXT_ARG)
+	if _, errno := r.e.Enter(0, 1, flags, unsafe.Pointer(&arg), unsafe.Sizeof(arg)); zcall.Errno(errno) != zcall.ETIME {
+		t.Fatalf("wait with timeout: got %v, want ETIME", zcall.Errno(errno))
+	}
+}
+
+// TestEmulatorMatchesKernel runs the same entries on a kernel ring and on
+// an Emulator and compares the completions.
+func TestEmulatorMatchesKernel(t *testing.T) {
+	run := func(push func(uint64, func(*zcall.IoUringSqe)), enter func(uintptr)) {
+		pr, pw := testPipe(t)
+		buf := make([]byte, 8)
+		push(1, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepWrite(sqe, pw, []byte("abc"), 0)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(2, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepRead(sqe, pr, buf, 0)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(3, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepRead(sqe, -1, buf, 0)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(4, zcall.PrepNop)
+		enter(4)
+
+		// A second chain, submitted on its own so that its completions do
+		// not interleave with those of the first.
+		ts := zcall.Timespec{Sec: 1}
+		push(5, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepNop(sqe)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(6, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepLinkTimeout(sqe, &ts, 0)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(7, zcall.PrepNop)
+		enter(7)
+
+		short := zcall.Timespec{Nsec: int64(time.Millisecond)}
+		push(8, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepTimeout(sqe, &short, 0, zcall.IORING_TIMEOUT_ETIME_SUCCESS)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(9, zcall.PrepNop)
+		enter(9)
+
+		// RECVMSG with MSG_WAITALL returns short at end of file.
+		sa, sb := testSocketpair(t)
+		zcall.Write(uintptr(sb), []byte("abc"))
+		zcall.Shutdown(uintptr(sb), zcall.SHUT_WR)
+		iov := zcall.Iovec{Base: &buf[0], Len: uint64(len(buf))}
+		msg := zcall.Msghdr{Iov: &iov, Iovlen: 1}
+		push(10, func(sqe *zcall.IoUringSqe) {
+			zcall.PrepRecvmsg(sqe, sa, &msg, zcall.MSG_WAITALL)
+			sqe.Flags |= zcall.IOSQE_IO_LINK
+		})
+		push(11, zcall.PrepNop)
+		enter(11)
+	}
+
+	kr := newTestRing(t, 8, 0)
+	run(kr.push, kr.enter)
+	var want []zcall.IoUringCqe
+	for c, ok := kr.pop(); ok; c, ok = kr.pop() {
+		want = append(want, c)
+	}
+
+	er := newEmuRing(t, 8, &zcall.IoUringParams{})
+	run(er.push, er.enter)
+	got := er.drain()
+	if !slices.Equal(got, want) {
+		t.Fatalf("emulator completions %+v, kernel %+v", got, want)
+	}
+}
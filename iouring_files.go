@@ -0,0 +1,156 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// FileTable is a sparse registered file table of an io_uring instance.
+//
+// Slots inside the allocation range set by SetAllocRange are handed out by
+// the kernel to direct-install requests that pass IORING_FILE_INDEX_ALLOC.
+// The remaining slots are tracked by the FileTable: Alloc reserves a free
+// one, Update and Clear record the slots they fill and empty, and Free
+// returns a slot whose direct descriptor was closed by other means.
+// A FileTable must be used by a single goroutine at a time.
+type FileTable struct {
+	fd       uintptr
+	nr       uint32
+	allocOff uint32
+	allocLen uint32
+	used     []uint64
+}
+
+// RegisterFileTable registers a sparse table of nr empty file slots with
+// the io_uring instance fd using IORING_REGISTER_FILES2.
+func RegisterFileTable(fd uintptr, nr uint32) (ft *FileTable, errno uintptr) {
+	reg := IoUringRsrcRegister{Nr: nr, Flags: IORING_RSRC_REGISTER_SPARSE}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_FILES2, unsafe.Pointer(&reg), unsafe.Sizeof(reg))
+	if errno != 0 {
+		return nil, errno
+	}
+	return &FileTable{fd: fd, nr: nr, used: make([]uint64, (nr+63)/64)}, 0
+}
+
+// Len returns the number of slots in the table.
+func (ft *FileTable) Len() uint32 {
+	return ft.nr
+}
+
+// SetAllocRange reserves slots [off, off+length) for kernel allocation
+// with IORING_REGISTER_FILE_ALLOC_RANGE. Alloc no longer returns slots
+// in that range.
+func (ft *FileTable) SetAllocRange(off, length uint32) (errno uintptr) {
+	r := IoUringFileIndexRange{Off: off, Len: length}
+	_, errno = IoUringRegister(ft.fd, IORING_REGISTER_FILE_ALLOC_RANGE, unsafe.Pointer(&r), 0)
+	if errno != 0 {
+		return errno
+	}
+	ft.allocOff, ft.allocLen = off, length
+	return 0
+}
+
+// Update installs fds into the slots starting at offset with
+// IORING_REGISTER_FILES_UPDATE2. An fd of -1 empties its slot and
+// IORING_REGISTER_FILES_SKIP leaves it unchanged. It returns the number of
+// slots the kernel updated.
+func (ft *FileTable) Update(offset uint32, fds []int32) (n uint32, errno uintptr) {
+	if len(fds) == 0 {
+		return 0, 0
+	}
+	up := IoUringRsrcUpdate2{
+		Offset: offset,
+		Data:   uint64(uintptr(unsafe.Pointer(unsafe.SliceData(fds)))),
+		Nr:     uint32(len(fds)),
+	}
+	r1, errno := IoUringRegister(ft.fd, IORING_REGISTER_FILES_UPDATE2, unsafe.Pointer(&up), unsafe.Sizeof(up))
+	if errno != 0 {
+		return 0, errno
+	}
+	n = uint32(r1)
+	for i, fd := range fds[:n] {
+		switch {
+		case fd == IORING_REGISTER_FILES_SKIP:
+		case fd < 0:
+			ft.mark(offset+uint32(i), false)
+		default:
+			ft.mark(offset+uint32(i), true)
+		}
+	}
+	return n, 0
+}
+
+// Clear empties nr slots starting at offset.
+func (ft *FileTable) Clear(offset, nr uint32) (errno uintptr) {
+	var empty [64]int32
+	for i := range empty {
+		empty[i] = -1
+	}
+	for nr > 0 {
+		chunk := min(nr, uint32(len(empty)))
+		n, errno := ft.Update(offset, empty[:chunk])
+		if errno != 0 {
+			return errno
+		}
+		offset += n
+		nr -= n
+	}
+	return 0
+}
+
+// Alloc reserves the lowest free slot outside the allocation range and
+// returns its index, for use as the fileIndex of a direct-install request.
+// ok is false when no such slot is free.
+func (ft *FileTable) Alloc() (slot uint32, ok bool) {
+	for i, w := range ft.used {
+		for w != ^uint64(0) {
+			bit := uint32(bits.TrailingZeros64(^w))
+			slot = uint32(i)*64 + bit
+			if slot >= ft.nr {
+				return 0, false
+			}
+			w |= 1 << bit
+			if ft.inAllocRange(slot) {
+				continue
+			}
+			ft.mark(slot, true)
+			return slot, true
+		}
+	}
+	return 0, false
+}
+
+// Free releases slot back to Alloc without touching the kernel table, for
+// slots emptied by a completed direct close.
+func (ft *FileTable) Free(slot uint32) {
+	if slot < ft.nr {
+		ft.mark(slot, false)
+	}
+}
+
+// Unregister removes the table with IORING_UNREGISTER_FILES.
+func (ft *FileTable) Unregister() (errno uintptr) {
+	_, errno = IoUringRegister(ft.fd, IORING_UNREGISTER_FILES, nil, 0)
+	if errno == 0 {
+		clear(ft.used)
+	}
+	return errno
+}
+
+func (ft *FileTable) inAllocRange(slot uint32) bool {
+	return slot-ft.allocOff < ft.allocLen
+}
+
+func (ft *FileTable) mark(slot uint32, used bool) {
+	if used {
+		ft.used[slot>>6] |= 1 << (slot & 63)
+	} else {
+		ft.used[slot>>6] &^= 1 << (slot & 63)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// newTestFileTable registers a sparse table of nr slots on r.
+func newTestFileTable(t *testing.T, r *testRing, nr uint32) *zcall.FileTable {
+	t.Helper()
+	ft, errno := zcall.RegisterFileTable(r.fd, nr)
+	if errno != 0 {
+		t.Fatalf("RegisterFileTable failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { ft.Unregister() })
+	return ft
+}
+
+func TestRsrcLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringRsrcRegister{}); got != 32 {
+		t.Errorf("sizeof(IoUringRsrcRegister) = %d, want 32", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringRsrcUpdate2{}); got != 32 {
+		t.Errorf("sizeof(IoUringRsrcUpdate2) = %d, want 32", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringFileIndexRange{}); got != 16 {
+		t.Errorf("sizeof(IoUringFileIndexRange) = %d, want 16", got)
+	}
+}
+
+func TestFileTableUpdate(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	ft := newTestFileTable(t, r, 4)
+	if ft.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", ft.Len())
+	}
+	rfd, wfd := testPipe(t)
+
+	n, errno := ft.Update(2, []int32{wfd, rfd})
+	if errno != 0 || n != 2 {
+		t.Fatalf("Update = %d, %v; want 2, 0", n, zcall.Errno(errno))
+	}
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWrite(sqe, 2, []byte("fixed"), 0)
+		sqe.Flags |= zcall.IOSQE_FIXED_FILE
+	})
+	expectRes(t, "WRITE fixed file", cqe, 5)
+	buf := make([]byte, 8)
+	if n, _ := zcall.Read(uintptr(rfd), buf); string(buf[:n]) != "fixed" {
+		t.Fatalf("read = %q, want %q", buf[:n], "fixed")
+	}
+
+	// Skip keeps slot 2, -1 empties slot 3.
+	if _, errno := ft.Update(2, []int32{zcall.IORING_REGISTER_FILES_SKIP, -1}); errno != 0 {
+		t.Fatalf("Update failed: %v", zcall.Errno(errno))
+	}
+	cqe = r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRead(sqe, 3, buf, 0)
+		sqe.Flags |= zcall.IOSQE_FIXED_FILE
+	})
+	expectRes(t, "READ cleared slot", cqe, -int32(zcall.EBADF))
+
+	if errno := ft.Clear(0, 4); errno != 0 {
+		t.Fatalf("Clear failed: %v", zcall.Errno(errno))
+	}
+	cqe = r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWrite(sqe, 2, []byte("x"), 0)
+		sqe.Flags |= zcall.IOSQE_FIXED_FILE
+	})
+	expectRes(t, "WRITE cleared slot", cqe, -int32(zcall.EBADF))
+}
+
+func TestFileTableAlloc(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	ft := newTestFileTable(t, r, 6)
+	if errno := ft.SetAllocRange(1, 2); errno != 0 {
+		t.Fatalf("SetAllocRange failed: %v", zcall.Errno(errno))
+	}
+
+	// Slots 1 and 2 belong to the kernel.
+	for _, want := range []uint32{0, 3, 4, 5} {
+		if slot, ok := ft.Alloc(); !ok || slot != want {
+			t.Fatalf("Alloc = %d, %v; want %d, true", slot, ok, want)
+		}
+	}
+	if slot, ok := ft.Alloc(); ok {
+		t.Fatalf("Alloc on full table = %d, true", slot)
+	}
+	ft.Free(4)
+	if slot, ok := ft.Alloc(); !ok || slot != 4 {
+		t.Fatalf("Alloc after Free = %d, %v; want 4, true", slot, ok)
+	}
+
+	// Clearing a slot makes it available again.
+	if errno := ft.Clear(3, 1); errno != 0 {
+		t.Fatalf("Clear failed: %v", zcall.Errno(errno))
+	}
+	if slot, ok := ft.Alloc(); !ok || slot != 3 {
+		t.Fatalf("Alloc after Clear = %d, %v; want 3, true", slot, ok)
+	}
+}
+
+func TestFileTableDirectInstall(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	ft := newTestFileTable(t, r, 8)
+	if errno := ft.SetAllocRange(4, 4); errno != 0 {
+		t.Fatalf("SetAllocRange failed: %v", zcall.Errno(errno))
+	}
+
+	// The kernel picks a slot from the allocation range.
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSocketDirect(sqe, zcall.AF_UNIX, zcall.SOCK_DGRAM, 0, 0, zcall.IORING_FILE_INDEX_ALLOC)
+	})
+	skipIfUnsupported(t, "SOCKET direct", cqe)
+	if cqe.Res < 4 || cqe.Res >= 8 {
+		t.Fatalf("SOCKET direct alloc res = %d, want a slot in [4, 8)", cqe.Res)
+	}
+
+	// An explicit slot from Alloc.
+	slot, ok := ft.Alloc()
+	if !ok {
+		t.Fatal("Alloc failed")
+	}
+	path := cstr("/dev/null")
+	expectRes(t, "OPENAT direct", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepOpenatDirect(sqe, zcall.AT_FDCWD, path, zcall.O_WRONLY, 0, slot)
+	}), 0)
+
+	cqe = r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepFixedFdInstall(sqe, int32(slot), zcall.IORING_FIXED_FD_NO_CLOEXEC)
+	})
+	skipIfUnsupported(t, "FIXED_FD_INSTALL", cqe)
+	if cqe.Res < 0 {
+		t.Fatalf("FIXED_FD_INSTALL failed: %v", zcall.Errno(-cqe.Res))
+	}
+	fd := uintptr(cqe.Res)
+	defer zcall.Close(fd)
+	if n, errno := zcall.Write(fd, []byte("promoted")); errno != 0 || n != 8 {
+		t.Fatalf("write to installed fd = %d, %v", n, zcall.Errno(errno))
+	}
+
+	expectRes(t, "CLOSE direct", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepCloseDirect(sqe, slot) }), 0)
+	ft.Free(slot)
+	expectRes(t, "CLOSE direct empty", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepCloseDirect(sqe, slot) }), -int32(zcall.EBADF))
+}
+
+func TestPrepDirectFileIndex(t *testing.T) {
+	var sqe zcall.IoUringSqe
+	zcall.PrepAcceptDirect(&sqe, 3, nil, nil, 0, 7)
+	if sqe.FileIndex != 8 {
+		t.Errorf("FileIndex = %d, want 8", sqe.FileIndex)
+	}
+	zcall.PrepAcceptDirect(&sqe, 3, nil, nil, 0, zcall.IORING_FILE_INDEX_ALLOC)
+	if sqe.FileIndex != zcall.IORING_FILE_INDEX_ALLOC {
+		t.Errorf("FileIndex = %#x, want IORING_FILE_INDEX_ALLOC", sqe.FileIndex)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// RegisterIOWQAff restricts the io-wq worker threads of the io_uring
+// instance fd, which run requests that cannot complete without blocking,
+// to the CPUs in set. With IORING_SETUP_SQPOLL, it applies to the workers
+// of the SQ thread.
+func RegisterIOWQAff(fd uintptr, set *CPUSet) (errno uintptr) {
+	_, errno = IoUringRegister(fd, IORING_REGISTER_IOWQ_AFF, unsafe.Pointer(set), unsafe.Sizeof(*set))
+	return errno
+}
+
+// UnregisterIOWQAff lets the io-wq workers of the io_uring instance fd run
+// on any CPU the submitting task may use again.
+func UnregisterIOWQAff(fd uintptr) (errno uintptr) {
+	_, errno = IoUringRegister(fd, IORING_UNREGISTER_IOWQ_AFF, nil, 0)
+	return errno
+}
+
+// RegisterIOWQMaxWorkers caps the io-wq workers of the io_uring instance fd
+// per NUMA node: bounded workers run requests of bounded duration, such as
+// regular file I/O, and unbounded workers run the rest, such as socket I/O.
+// A zero limit leaves that limit unchanged, so passing two zeros queries
+// the current limits. It returns the limits in effect before the call.
+func RegisterIOWQMaxWorkers(fd uintptr, bounded, unbounded uint32) (prevBounded, prevUnbounded uint32, errno uintptr) {
+	counts := [2]uint32{bounded, unbounded}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_IOWQ_MAX_WORKERS, unsafe.Pointer(&counts), 2)
+	return counts[0], counts[1], errno
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestRegisterIOWQAff(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	var set zcall.CPUSet
+	if _, errno := zcall.SchedGetaffinity(0, unsafe.Sizeof(set), unsafe.Pointer(&set)); errno != 0 {
+		t.Fatalf("SchedGetaffinity failed: %v", zcall.Errno(errno))
+	}
+	if errno := zcall.RegisterIOWQAff(r.fd, &set); errno != 0 {
+		t.Fatalf("RegisterIOWQAff failed: %v", zcall.Errno(errno))
+	}
+
+	// Blocking requests still run on the pinned workers.
+	_, fd := testFile(t)
+	expectRes(t, "WRITE with IOSQE_ASYNC", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWrite(sqe, fd, []byte("pinned"), 0)
+		sqe.Flags |= zcall.IOSQE_ASYNC
+	}), 6)
+
+	if errno := zcall.UnregisterIOWQAff(r.fd); errno != 0 {
+		t.Fatalf("UnregisterIOWQAff failed: %v", zcall.Errno(errno))
+	}
+}
+
+func TestRegisterIOWQMaxWorkers(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	b, u, errno := zcall.RegisterIOWQMaxWorkers(r.fd, 0, 0)
+	if errno != 0 {
+		t.Fatalf("RegisterIOWQMaxWorkers query failed: %v", zcall.Errno(errno))
+	}
+	if b == 0 || u == 0 {
+		t.Fatalf("default limits = %d, %d", b, u)
+	}
+	if pb, pu, errno := zcall.RegisterIOWQMaxWorkers(r.fd, 2, 0); errno != 0 || pb != b || pu != u {
+		t.Fatalf("RegisterIOWQMaxWorkers = %d, %d, %v; want %d, %d", pb, pu, zcall.Errno(errno), b, u)
+	}
+	if pb, pu, _ := zcall.RegisterIOWQMaxWorkers(r.fd, 0, 3); pb != 2 || pu != u {
+		t.Fatalf("limits after capping bounded = %d, %d; want 2, %d", pb, pu, u)
+	}
+	if pb, pu, _ := zcall.RegisterIOWQMaxWorkers(r.fd, 0, 0); pb != 2 || pu != 3 {
+		t.Fatalf("limits = %d, %d; want 2, 3", pb, pu)
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// SendMsgRing posts the IORING_OP_MSG_RING request sqe directly with
+// IORING_REGISTER_SEND_MSG_RING, without a submitting ring. It lets a
+// thread that owns no ring post a completion into the target ring named by
+// sqe.Fd. Only IORING_MSG_DATA messages, as prepared by PrepMsgRing and
+// PrepMsgRingCQEFlags, are supported.
+func SendMsgRing(sqe *IoUringSqe) (errno uintptr) {
+	_, errno = IoUringRegister(^uintptr(0), IORING_REGISTER_SEND_MSG_RING, unsafe.Pointer(sqe), 1)
+	return errno
+}
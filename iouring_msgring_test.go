@@ -0,0 +1,114 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestPrepMsgRingCQEFlags(t *testing.T) {
+	src := newTestRing(t, 8, 0)
+	dst := newTestRing(t, 8, 0)
+
+	const cqeFlags = 0x100
+	cqe := src.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepMsgRingCQEFlags(sqe, int32(dst.fd), 3, 0xf1a6, 0, cqeFlags)
+	})
+	skipIfUnsupported(t, "MSG_RING flags pass", cqe)
+	expectRes(t, "MSG_RING flags pass", cqe, 0)
+	dst.enter(1)
+	got, ok := dst.pop()
+	if !ok || got.UserData != 0xf1a6 || got.Res != 3 || got.Flags != cqeFlags {
+		t.Fatalf("target ring completion = %+v, %v", got, ok)
+	}
+}
+
+func TestPrepMsgRingFd(t *testing.T) {
+	src := newTestRing(t, 8, 0)
+	dst := newTestRing(t, 8, 0)
+	rfd, wfd := testPipe(t)
+
+	srcFiles := newTestFileTable(t, src, 1)
+	if _, errno := srcFiles.Update(0, []int32{wfd}); errno != 0 {
+		t.Fatalf("Update failed: %v", zcall.Errno(errno))
+	}
+	newTestFileTable(t, dst, 4)
+
+	expectRes(t, "MSG_RING send fd", src.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepMsgRingFd(sqe, int32(dst.fd), 0, 2, 0xfd, 0)
+	}), 0)
+	dst.enter(1)
+	got, ok := dst.pop()
+	if !ok || got.UserData != 0xfd {
+		t.Fatalf("target ring completion = %+v, %v", got, ok)
+	}
+
+	// The target ring now owns the pipe in slot 2.
+	expectRes(t, "WRITE through passed fd", dst.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWrite(sqe, 2, []byte("passed"), 0)
+		sqe.Flags |= zcall.IOSQE_FIXED_FILE
+	}), 6)
+	buf := make([]byte, 8)
+	if n, _ := zcall.Read(uintptr(rfd), buf); string(buf[:n]) != "passed" {
+		t.Fatalf("pipe read = %q", buf[:n])
+	}
+}
+
+func TestPrepMsgRingFdAllocSkip(t *testing.T) {
+	src := newTestRing(t, 8, 0)
+	dst := newTestRing(t, 8, 0)
+	_, wfd := testPipe(t)
+
+	srcFiles := newTestFileTable(t, src, 1)
+	if _, errno := srcFiles.Update(0, []int32{wfd}); errno != 0 {
+		t.Fatalf("Update failed: %v", zcall.Errno(errno))
+	}
+	dstFiles := newTestFileTable(t, dst, 4)
+	if errno := dstFiles.SetAllocRange(2, 2); errno != 0 {
+		t.Fatalf("SetAllocRange failed: %v", zcall.Errno(errno))
+	}
+
+	cqe := src.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepMsgRingFd(sqe, int32(dst.fd), 0, zcall.IORING_FILE_INDEX_ALLOC, 0, zcall.IORING_MSG_RING_CQE_SKIP)
+	})
+	if cqe.Res != 2 && cqe.Res != 3 {
+		t.Fatalf("MSG_RING send fd res = %d, want an allocated slot in [2, 4)", cqe.Res)
+	}
+	if _, errno := zcall.IoUringEnter(dst.fd, 0, 0, zcall.IORING_ENTER_GETEVENTS, nil, 0); errno != 0 {
+		t.Fatalf("IoUringEnter failed: %v", zcall.Errno(errno))
+	}
+	if got, ok := dst.pop(); ok {
+		t.Fatalf("IORING_MSG_RING_CQE_SKIP posted %+v", got)
+	}
+}
+
+func TestSendMsgRing(t *testing.T) {
+	dst := newTestRing(t, 8, 0)
+
+	var sqe zcall.IoUringSqe
+	zcall.PrepMsgRing(&sqe, int32(dst.fd), 9, 0xca11, 0)
+	errno := zcall.SendMsgRing(&sqe)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_SEND_MSG_RING not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("SendMsgRing failed: %v", zcall.Errno(errno))
+	}
+	dst.enter(1)
+	got, ok := dst.pop()
+	if !ok || got.UserData != 0xca11 || got.Res != 9 {
+		t.Fatalf("target ring completion = %+v, %v", got, ok)
+	}
+
+	// Passing a file requires a source ring.
+	zcall.PrepMsgRingFd(&sqe, int32(dst.fd), 0, 0, 0, 0)
+	if errno := zcall.SendMsgRing(&sqe); errno == 0 {
+		t.Fatal("SendMsgRing accepted IORING_MSG_SEND_FD")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// RegisterNAPI enables NAPI busy polling on the io_uring instance fd.
+// Waits for completions busy poll the receive queues of the tracked NAPI
+// instances for up to busyPollUsec microseconds. tracking is
+// IO_URING_NAPI_TRACKING_DYNAMIC to track the NAPI instances of the
+// sockets the ring operates on, IO_URING_NAPI_TRACKING_STATIC to poll only
+// the IDs added with NAPIAddID, or IO_URING_NAPI_TRACKING_INACTIVE. The
+// static ID list is cleared. It returns the settings in effect before the
+// call.
+func RegisterNAPI(fd uintptr, busyPollUsec uint32, preferBusyPoll bool, tracking uint32) (prev IoUringNapi, errno uintptr) {
+	prev = IoUringNapi{
+		BusyPollTo: busyPollUsec,
+		Opcode:     IO_URING_NAPI_REGISTER_OP,
+		OpParam:    tracking,
+	}
+	if preferBusyPoll {
+		prev.PreferBusyPoll = 1
+	}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_NAPI, unsafe.Pointer(&prev), 1)
+	return prev, errno
+}
+
+// UnregisterNAPI disables NAPI busy polling on the io_uring instance fd and
+// returns the settings that were in effect.
+func UnregisterNAPI(fd uintptr) (prev IoUringNapi, errno uintptr) {
+	_, errno = IoUringRegister(fd, IORING_UNREGISTER_NAPI, unsafe.Pointer(&prev), 1)
+	return prev, errno
+}
+
+// NAPIAddID adds the NAPI instance id to the busy poll list of an io_uring
+// instance registered with IO_URING_NAPI_TRACKING_STATIC. The NAPI ID of a
+// socket is read with the SO_INCOMING_NAPI_ID socket option.
+func NAPIAddID(fd uintptr, id uint32) (errno uintptr) {
+	return napiStaticOp(fd, IO_URING_NAPI_STATIC_ADD_ID, id)
+}
+
+// NAPIDelID removes the NAPI instance id from the busy poll list of an
+// io_uring instance registered with IO_URING_NAPI_TRACKING_STATIC.
+func NAPIDelID(fd uintptr, id uint32) (errno uintptr) {
+	return napiStaticOp(fd, IO_URING_NAPI_STATIC_DEL_ID, id)
+}
+
+func napiStaticOp(fd uintptr, op uint8, id uint32) (errno uintptr) {
+	napi := IoUringNapi{Opcode: op, OpParam: id}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_NAPI, unsafe.Pointer(&napi), 1)
+	return errno
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestIoUringNapiLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringNapi{}); got != 16 {
+		t.Errorf("sizeof(IoUringNapi) = %d, want 16", got)
+	}
+}
+
+func TestRegisterNAPI(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	prev, errno := zcall.RegisterNAPI(r.fd, 50, true, zcall.IO_URING_NAPI_TRACKING_DYNAMIC)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_NAPI not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("RegisterNAPI failed: %v", zcall.Errno(errno))
+	}
+	if prev.BusyPollTo != 0 || prev.OpParam != zcall.IO_URING_NAPI_TRACKING_INACTIVE {
+		t.Fatalf("previous settings = %+v, want inactive", prev)
+	}
+
+	prev, errno = zcall.RegisterNAPI(r.fd, 20, false, zcall.IO_URING_NAPI_TRACKING_DYNAMIC)
+	if errno != 0 {
+		t.Fatalf("second RegisterNAPI failed: %v", zcall.Errno(errno))
+	}
+	want := zcall.IoUringNapi{BusyPollTo: 50, PreferBusyPoll: 1, OpParam: zcall.IO_URING_NAPI_TRACKING_DYNAMIC}
+	if prev != want {
+		t.Fatalf("second RegisterNAPI = %+v, want %+v", prev, want)
+	}
+
+	prev, errno = zcall.UnregisterNAPI(r.fd)
+	if errno != 0 {
+		t.Fatalf("UnregisterNAPI failed: %v", zcall.Errno(errno))
+	}
+	want = zcall.IoUringNapi{BusyPollTo: 20, OpParam: zcall.IO_URING_NAPI_TRACKING_DYNAMIC}
+	if prev != want {
+		t.Fatalf("UnregisterNAPI = %+v, want %+v", prev, want)
+	}
+	if prev, _ := zcall.UnregisterNAPI(r.fd); prev.BusyPollTo != 0 {
+		t.Fatalf("UnregisterNAPI after UnregisterNAPI = %+v, want busy polling disabled", prev)
+	}
+}
+
+func TestNAPIStaticIDs(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	if _, errno := zcall.RegisterNAPI(r.fd, 10, false, zcall.IO_URING_NAPI_TRACKING_DYNAMIC); zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_NAPI not supported on this kernel")
+	}
+	// Static IDs are only accepted with static tracking.
+	if errno := zcall.NAPIAddID(r.fd, 1<<20); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("NAPIAddID with dynamic tracking errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+	if _, errno := zcall.RegisterNAPI(r.fd, 10, false, zcall.IO_URING_NAPI_TRACKING_STATIC); errno != 0 {
+		t.Fatalf("RegisterNAPI failed: %v", zcall.Errno(errno))
+	}
+	// Registering again reports the strategy the kernel accepted.
+	prev, _ := zcall.RegisterNAPI(r.fd, 10, false, zcall.IO_URING_NAPI_TRACKING_STATIC)
+	if prev.OpParam != zcall.IO_URING_NAPI_TRACKING_STATIC {
+		t.Skip("IO_URING_NAPI_TRACKING_STATIC not supported on this kernel")
+	}
+	if errno := zcall.NAPIAddID(r.fd, 1<<20); errno != 0 {
+		t.Fatalf("NAPIAddID failed: %v", zcall.Errno(errno))
+	}
+	if errno := zcall.NAPIDelID(r.fd, 1<<20); errno != 0 {
+		t.Fatalf("NAPIDelID failed: %v", zcall.Errno(errno))
+	}
+}
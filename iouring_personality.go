@@ -0,0 +1,31 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+// RegisterPersonality registers the credentials of the calling thread with
+// the io_uring instance fd and returns the personality id that refers to
+// them. Requests that carry the id run with those credentials instead of
+// the credentials of the submitting task. Go threads share credentials
+// unless they were changed with raw syscalls on a locked thread.
+func RegisterPersonality(fd uintptr) (id uint16, errno uintptr) {
+	r1, errno := IoUringRegister(fd, IORING_REGISTER_PERSONALITY, nil, 0)
+	return uint16(r1), errno
+}
+
+// UnregisterPersonality removes the personality id from the io_uring
+// instance fd. Requests already submitted with the id are not affected.
+func UnregisterPersonality(fd uintptr, id uint16) (errno uintptr) {
+	_, errno = IoUringRegister(fd, IORING_UNREGISTER_PERSONALITY, nil, uintptr(id))
+	return errno
+}
+
+// SetPersonality makes sqe run with the credentials registered as
+// personality id. Prep functions clear the field, so call it after them.
+// An id of 0 selects the credentials of the submitting task.
+func (sqe *IoUringSqe) SetPersonality(id uint16) {
+	sqe.Personality = id
+}
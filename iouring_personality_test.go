@@ -0,0 +1,104 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestRegisterPersonality(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	id, errno := zcall.RegisterPersonality(r.fd)
+	if errno != 0 {
+		t.Fatalf("RegisterPersonality failed: %v", zcall.Errno(errno))
+	}
+	if id == 0 {
+		t.Fatal("RegisterPersonality returned id 0")
+	}
+
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.SetPersonality(id)
+	})
+	expectRes(t, "nop with personality", cqe, 0)
+
+	if errno := zcall.UnregisterPersonality(r.fd, id); errno != 0 {
+		t.Fatalf("UnregisterPersonality failed: %v", zcall.Errno(errno))
+	}
+	cqe = r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.SetPersonality(id)
+	})
+	expectRes(t, "nop with unregistered personality", cqe, -int32(zcall.EINVAL))
+
+	if errno := zcall.UnregisterPersonality(r.fd, id); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("second UnregisterPersonality: got %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+// setresuid sets the user IDs of the calling thread only, unlike
+// syscall.Setresuid, which applies them to every thread of the process.
+func setresuid(ruid, euid, suid uintptr) (errno uintptr) {
+	num := uintptr(147) // arm64, riscv64, loong64
+	if runtime.GOARCH == "amd64" {
+		num = 117
+	}
+	_, errno = zcall.Syscall4(num, ruid, euid, suid, 0)
+	return errno
+}
+
+// TestPersonalityCredentials registers the credentials of an unprivileged
+// user and checks that requests carrying them are denied access the ring
+// owner has.
+func TestPersonalityCredentials(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to switch credentials")
+	}
+	const nobody = 65534
+	r := newTestRing(t, 4, 0)
+
+	// Keeping the saved uid lets the locked thread switch back.
+	var id uint16
+	var errno uintptr
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		if e := setresuid(^uintptr(0), nobody, ^uintptr(0)); e != 0 {
+			errno = e
+			return
+		}
+		id, errno = zcall.RegisterPersonality(r.fd)
+		if e := setresuid(^uintptr(0), 0, ^uintptr(0)); e == 0 {
+			runtime.UnlockOSThread()
+		}
+	}()
+	<-done
+	if errno != 0 {
+		t.Fatalf("register as nobody: %v", zcall.Errno(errno))
+	}
+	defer zcall.UnregisterPersonality(r.fd, id)
+
+	path := cstr(filepath.Join(t.TempDir(), "secret"))
+	open := func(personality uint16) zcall.IoUringCqe {
+		return r.run(func(sqe *zcall.IoUringSqe) {
+			zcall.PrepOpenat(sqe, zcall.AT_FDCWD, path, zcall.O_CREAT|zcall.O_WRONLY|zcall.O_CLOEXEC, 0o600)
+			sqe.SetPersonality(personality)
+		})
+	}
+	expectRes(t, "openat as nobody", open(id), -int32(zcall.EACCES))
+	cqe := open(0)
+	if cqe.Res < 0 {
+		t.Fatalf("openat as owner: %v", zcall.Errno(-cqe.Res))
+	}
+	zcall.Close(uintptr(cqe.Res))
+}
@@ -0,0 +1,601 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// SQE preparation.
+//
+// Each Prep function resets every field of the SQE, including UserData and
+// Flags, and then fills in the fields for one opcode. Set UserData and any
+// IOSQE_* flags after preparing the entry.
+//
+// The SQE stores raw addresses. Buffers, paths, and argument structs must
+// stay valid and must not be garbage collected until the operation
+// completes. Paths are NUL-terminated byte strings.
+
+// addrOf returns the address of p as stored in an SQE.
+func addrOf(p unsafe.Pointer) uint64 {
+	return uint64(uintptr(p))
+}
+
+// sliceAddr returns the address of the first element of b, or 0.
+func sliceAddr(b []byte) uint64 {
+	return uint64(uintptr(unsafe.Pointer(unsafe.SliceData(b))))
+}
+
+// prepRW fills the fields shared by most opcodes and clears the rest.
+func prepRW(sqe *IoUringSqe, op uint8, fd int32, addr uint64, n uint32, off uint64) {
+	*sqe = IoUringSqe{Opcode: op, Fd: fd, Addr: addr, Len: n, Off: off}
+}
+
+// setTargetFixedFile directs the file created by sqe into a registered
+// file slot. The kernel expects the slot index plus one, except for
+// IORING_FILE_INDEX_ALLOC.
+func setTargetFixedFile(sqe *IoUringSqe, fileIndex uint32) {
+	if fileIndex != IORING_FILE_INDEX_ALLOC {
+		fileIndex++
+	}
+	sqe.FileIndex = fileIndex
+}
+
+// Cmd returns the command payload area of an IORING_OP_URING_CMD entry.
+func (sqe *IoUringSqe) Cmd() *[16]byte {
+	return (*[16]byte)(unsafe.Pointer(&sqe.Addr3))
+}
+
+// Cmd returns the command payload area of an IORING_OP_URING_CMD128 entry
+// or an IORING_OP_URING_CMD entry in an IORING_SETUP_SQE128 ring.
+func (sqe *IoUringSqe128) Cmd() *[80]byte {
+	return (*[80]byte)(unsafe.Pointer(&sqe.Addr3))
+}
+
+// PrepNop prepares a no-op request.
+func PrepNop(sqe *IoUringSqe) {
+	prepRW(sqe, IORING_OP_NOP, -1, 0, 0, 0)
+}
+
+// PrepNop128 prepares a no-op request that occupies a 128-byte SQE. It is
+// only valid in a ring created with IORING_SETUP_SQE_MIXED, on an entry
+// obtained from SubmissionQueue.NextSQE128.
+func PrepNop128(sqe *IoUringSqe) {
+	prepRW(sqe, IORING_OP_NOP128, -1, 0, 0, 0)
+}
+
+// PrepReadv prepares a vectored read at offset.
+// An offset of ^uint64(0) reads from the current file position.
+func PrepReadv(sqe *IoUringSqe, fd int32, iov unsafe.Pointer, nrVecs uint32, offset uint64) {
+	prepRW(sqe, IORING_OP_READV, fd, addrOf(iov), nrVecs, offset)
+}
+
+// PrepWritev prepares a vectored write at offset.
+// An offset of ^uint64(0) writes at the current file position.
+func PrepWritev(sqe *IoUringSqe, fd int32, iov unsafe.Pointer, nrVecs uint32, offset uint64) {
+	prepRW(sqe, IORING_OP_WRITEV, fd, addrOf(iov), nrVecs, offset)
+}
+
+// PrepReadvFixed prepares a vectored read into registered buffer bufIndex.
+func PrepReadvFixed(sqe *IoUringSqe, fd int32, iov unsafe.Pointer, nrVecs uint32, offset uint64, bufIndex uint16) {
+	prepRW(sqe, IORING_OP_READV_FIXED, fd, addrOf(iov), nrVecs, offset)
+	sqe.BufIndex = bufIndex
+}
+
+// PrepWritevFixed prepares a vectored write from registered buffer bufIndex.
+func PrepWritevFixed(sqe *IoUringSqe, fd int32, iov unsafe.Pointer, nrVecs uint32, offset uint64, bufIndex uint16) {
+	prepRW(sqe, IORING_OP_WRITEV_FIXED, fd, addrOf(iov), nrVecs, offset)
+	sqe.BufIndex = bufIndex
+}
+
+// PrepRead prepares a read into buf at offset.
+// An offset of ^uint64(0) reads from the current file position.
+func PrepRead(sqe *IoUringSqe, fd int32, buf []byte, offset uint64) {
+	prepRW(sqe, IORING_OP_READ, fd, sliceAddr(buf), uint32(len(buf)), offset)
+}
+
+// PrepWrite prepares a write of buf at offset.
+// An offset of ^uint64(0) writes at the current file position.
+func PrepWrite(sqe *IoUringSqe, fd int32, buf []byte, offset uint64) {
+	prepRW(sqe, IORING_OP_WRITE, fd, sliceAddr(buf), uint32(len(buf)), offset)
+}
+
+// PrepReadFixed prepares a read into buf, which must lie within
+// registered buffer bufIndex.
+func PrepReadFixed(sqe *IoUringSqe, fd int32, buf []byte, offset uint64, bufIndex uint16) {
+	prepRW(sqe, IORING_OP_READ_FIXED, fd, sliceAddr(buf), uint32(len(buf)), offset)
+	sqe.BufIndex = bufIndex
+}
+
+// PrepWriteFixed prepares a write of buf, which must lie within
+// registered buffer bufIndex.
+func PrepWriteFixed(sqe *IoUringSqe, fd int32, buf []byte, offset uint64, bufIndex uint16) {
+	prepRW(sqe, IORING_OP_WRITE_FIXED, fd, sliceAddr(buf), uint32(len(buf)), offset)
+	sqe.BufIndex = bufIndex
+}
+
+// PrepReadMultishot prepares a multishot read that selects buffers from
+// provided buffer group bgid and posts a completion per read.
+func PrepReadMultishot(sqe *IoUringSqe, fd int32, nbytes uint32, offset uint64, bgid uint16) {
+	prepRW(sqe, IORING_OP_READ_MULTISHOT, fd, 0, nbytes, offset)
+	sqe.BufIndex = bgid
+	sqe.Flags = IOSQE_BUFFER_SELECT
+}
+
+// PrepFsync prepares an fsync. Flags may include IORING_FSYNC_DATASYNC.
+func PrepFsync(sqe *IoUringSqe, fd int32, flags uint32) {
+	prepRW(sqe, IORING_OP_FSYNC, fd, 0, 0, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepSyncFileRange prepares a sync_file_range.
+func PrepSyncFileRange(sqe *IoUringSqe, fd int32, length uint32, offset uint64, flags uint32) {
+	prepRW(sqe, IORING_OP_SYNC_FILE_RANGE, fd, 0, length, offset)
+	sqe.OpFlags = flags
+}
+
+// PrepFallocate prepares an fallocate.
+func PrepFallocate(sqe *IoUringSqe, fd int32, mode uint32, offset, length uint64) {
+	prepRW(sqe, IORING_OP_FALLOCATE, fd, length, mode, offset)
+}
+
+// PrepFadvise prepares a posix_fadvise.
+func PrepFadvise(sqe *IoUringSqe, fd int32, offset uint64, length uint32, advice uint32) {
+	prepRW(sqe, IORING_OP_FADVISE, fd, 0, length, offset)
+	sqe.OpFlags = advice
+}
+
+// PrepMadvise prepares an madvise.
+func PrepMadvise(sqe *IoUringSqe, addr unsafe.Pointer, length uint32, advice uint32) {
+	prepRW(sqe, IORING_OP_MADVISE, -1, addrOf(addr), length, 0)
+	sqe.OpFlags = advice
+}
+
+// PrepFtruncate prepares an ftruncate.
+func PrepFtruncate(sqe *IoUringSqe, fd int32, length int64) {
+	prepRW(sqe, IORING_OP_FTRUNCATE, fd, 0, 0, uint64(length))
+}
+
+// PrepPollAdd prepares a poll for the events in pollMask (POLLIN, POLLOUT, ...).
+func PrepPollAdd(sqe *IoUringSqe, fd int32, pollMask uint32) {
+	prepRW(sqe, IORING_OP_POLL_ADD, fd, 0, 0, 0)
+	sqe.OpFlags = pollMask
+}
+
+// PrepPollRemove prepares the removal of the poll request with userData.
+func PrepPollRemove(sqe *IoUringSqe, userData uint64) {
+	prepRW(sqe, IORING_OP_POLL_REMOVE, -1, userData, 0, 0)
+}
+
+// PrepTimeout prepares a timeout that completes after ts elapses or after
+// count other completions, whichever is first. A count of 0 waits for ts only.
+// Flags may include IORING_TIMEOUT_ABS and the clock selection flags.
+func PrepTimeout(sqe *IoUringSqe, ts *Timespec, count uint32, flags uint32) {
+	prepRW(sqe, IORING_OP_TIMEOUT, -1, addrOf(unsafe.Pointer(ts)), 1, uint64(count))
+	sqe.OpFlags = flags
+}
+
+// PrepTimeoutRemove prepares the removal of the timeout with userData.
+func PrepTimeoutRemove(sqe *IoUringSqe, userData uint64, flags uint32) {
+	prepRW(sqe, IORING_OP_TIMEOUT_REMOVE, -1, userData, 0, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepLinkTimeout prepares a timeout for the previous linked request.
+func PrepLinkTimeout(sqe *IoUringSqe, ts *Timespec, flags uint32) {
+	prepRW(sqe, IORING_OP_LINK_TIMEOUT, -1, addrOf(unsafe.Pointer(ts)), 1, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepCancel prepares the cancellation of the request with userData.
+func PrepCancel(sqe *IoUringSqe, userData uint64, flags uint32) {
+	prepRW(sqe, IORING_OP_ASYNC_CANCEL, -1, userData, 0, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepCancelFd prepares the cancellation of the requests on fd, or on
+// the registered file fd with IORING_ASYNC_CANCEL_FD_FIXED in flags.
+// Without IORING_ASYNC_CANCEL_ALL, only the first match is cancelled.
+func PrepCancelFd(sqe *IoUringSqe, fd int32, flags uint32) {
+	prepRW(sqe, IORING_OP_ASYNC_CANCEL, fd, 0, 0, 0)
+	sqe.OpFlags = flags | IORING_ASYNC_CANCEL_FD
+}
+
+// PrepAsyncCancel prepares the asynchronous form of CancelSync: the
+// cancellation of the requests matching the criteria of reg, whose Timeout
+// is ignored. The completion result is the number of requests cancelled
+// with IORING_ASYNC_CANCEL_ALL or IORING_ASYNC_CANCEL_ANY, and 0 otherwise.
+func PrepAsyncCancel(sqe *IoUringSqe, reg *IoUringSyncCancelReg) {
+	prepRW(sqe, IORING_OP_ASYNC_CANCEL, reg.Fd, reg.Addr, uint32(reg.Opcode), 0)
+	sqe.OpFlags = reg.Flags
+}
+
+// PrepSend prepares a send of buf on a socket.
+func PrepSend(sqe *IoUringSqe, fd int32, buf []byte, flags uint32) {
+	prepRW(sqe, IORING_OP_SEND, fd, sliceAddr(buf), uint32(len(buf)), 0)
+	sqe.OpFlags = flags
+}
+
+// PrepRecv prepares a receive into buf from a socket.
+func PrepRecv(sqe *IoUringSqe, fd int32, buf []byte, flags uint32) {
+	prepRW(sqe, IORING_OP_RECV, fd, sliceAddr(buf), uint32(len(buf)), 0)
+	sqe.OpFlags = flags
+}
+
+// PrepRecvMultishot prepares a multishot receive that selects buffers
+// from provided buffer group bgid and posts a completion per message,
+// with IORING_CQE_F_MORE set while the request stays armed.
+func PrepRecvMultishot(sqe *IoUringSqe, fd int32, flags uint32, bgid uint16) {
+	prepRW(sqe, IORING_OP_RECV, fd, 0, 0, 0)
+	sqe.OpFlags = flags
+	sqe.Ioprio = IORING_RECV_MULTISHOT
+	sqe.Flags = IOSQE_BUFFER_SELECT
+	sqe.BufIndex = bgid
+}
+
+// PrepSendZC prepares a zero-copy send of buf. zcFlags are the
+// IORING_RECVSEND_* and IORING_SEND_ZC_* flags carried in the ioprio field.
+// The request posts a second, notification completion once buf is no
+// longer referenced by the kernel.
+func PrepSendZC(sqe *IoUringSqe, fd int32, buf []byte, flags uint32, zcFlags uint16) {
+	prepRW(sqe, IORING_OP_SEND_ZC, fd, sliceAddr(buf), uint32(len(buf)), 0)
+	sqe.OpFlags = flags
+	sqe.Ioprio = zcFlags
+}
+
+// PrepSendmsg prepares a sendmsg.
+func PrepSendmsg(sqe *IoUringSqe, fd int32, msg *Msghdr, flags uint32) {
+	prepRW(sqe, IORING_OP_SENDMSG, fd, addrOf(unsafe.Pointer(msg)), 1, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepRecvmsg prepares a recvmsg.
+func PrepRecvmsg(sqe *IoUringSqe, fd int32, msg *Msghdr, flags uint32) {
+	prepRW(sqe, IORING_OP_RECVMSG, fd, addrOf(unsafe.Pointer(msg)), 1, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepSendmsgZC prepares a zero-copy sendmsg. Like PrepSendZC, it posts a
+// notification completion once the message buffers are released.
+func PrepSendmsgZC(sqe *IoUringSqe, fd int32, msg *Msghdr, flags uint32) {
+	prepRW(sqe, IORING_OP_SENDMSG_ZC, fd, addrOf(unsafe.Pointer(msg)), 1, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepRecvZC prepares a zero-copy receive through the registered zcrx
+// interface queue ifqIdx. The kernel requires IORING_RECV_MULTISHOT in ioprio.
+func PrepRecvZC(sqe *IoUringSqe, fd int32, ifqIdx uint32, length uint32, ioprio uint16) {
+	prepRW(sqe, IORING_OP_RECV_ZC, fd, 0, length, 0)
+	sqe.Ioprio = ioprio
+	sqe.FileIndex = ifqIdx
+}
+
+// PrepAccept prepares an accept. addr and addrlen may be nil.
+// Flags are accept4 flags such as SOCK_NONBLOCK and SOCK_CLOEXEC.
+func PrepAccept(sqe *IoUringSqe, fd int32, addr, addrlen unsafe.Pointer, flags uint32) {
+	prepRW(sqe, IORING_OP_ACCEPT, fd, addrOf(addr), 0, addrOf(addrlen))
+	sqe.OpFlags = flags
+}
+
+// PrepAcceptDirect prepares an accept that installs the new socket into
+// registered file slot fileIndex, or into a free slot of the allocation
+// range when fileIndex is IORING_FILE_INDEX_ALLOC.
+func PrepAcceptDirect(sqe *IoUringSqe, fd int32, addr, addrlen unsafe.Pointer, flags uint32, fileIndex uint32) {
+	PrepAccept(sqe, fd, addr, addrlen, flags)
+	setTargetFixedFile(sqe, fileIndex)
+}
+
+// PrepAcceptMultishot prepares a multishot accept that posts a completion
+// per accepted connection, with IORING_CQE_F_MORE set while the request
+// stays armed.
+func PrepAcceptMultishot(sqe *IoUringSqe, fd int32, addr, addrlen unsafe.Pointer, flags uint32) {
+	PrepAccept(sqe, fd, addr, addrlen, flags)
+	sqe.Ioprio = IORING_ACCEPT_MULTISHOT
+}
+
+// PrepAcceptMultishotDirect is PrepAcceptMultishot installing each
+// connection into a free slot of the file allocation range.
+func PrepAcceptMultishotDirect(sqe *IoUringSqe, fd int32, addr, addrlen unsafe.Pointer, flags uint32) {
+	PrepAcceptMultishot(sqe, fd, addr, addrlen, flags)
+	setTargetFixedFile(sqe, IORING_FILE_INDEX_ALLOC)
+}
+
+// PrepConnect prepares a connect.
+func PrepConnect(sqe *IoUringSqe, fd int32, addr unsafe.Pointer, addrlen uint32) {
+	prepRW(sqe, IORING_OP_CONNECT, fd, addrOf(addr), 0, uint64(addrlen))
+}
+
+// PrepSocket prepares a socket creation.
+func PrepSocket(sqe *IoUringSqe, domain, typ, protocol int32, flags uint32) {
+	prepRW(sqe, IORING_OP_SOCKET, domain, 0, uint32(protocol), uint64(typ))
+	sqe.OpFlags = flags
+}
+
+// PrepSocketDirect prepares a socket creation into registered file slot
+// fileIndex, or IORING_FILE_INDEX_ALLOC.
+func PrepSocketDirect(sqe *IoUringSqe, domain, typ, protocol int32, flags uint32, fileIndex uint32) {
+	PrepSocket(sqe, domain, typ, protocol, flags)
+	setTargetFixedFile(sqe, fileIndex)
+}
+
+// PrepBind prepares a bind.
+func PrepBind(sqe *IoUringSqe, fd int32, addr unsafe.Pointer, addrlen uint32) {
+	prepRW(sqe, IORING_OP_BIND, fd, addrOf(addr), 0, uint64(addrlen))
+}
+
+// PrepListen prepares a listen.
+func PrepListen(sqe *IoUringSqe, fd int32, backlog uint32) {
+	prepRW(sqe, IORING_OP_LISTEN, fd, 0, backlog, 0)
+}
+
+// PrepShutdown prepares a shutdown. how is SHUT_RD, SHUT_WR, or SHUT_RDWR.
+func PrepShutdown(sqe *IoUringSqe, fd int32, how uint32) {
+	prepRW(sqe, IORING_OP_SHUTDOWN, fd, 0, how, 0)
+}
+
+// PrepOpenat prepares an openat.
+func PrepOpenat(sqe *IoUringSqe, dfd int32, path *byte, flags uint32, mode uint32) {
+	prepRW(sqe, IORING_OP_OPENAT, dfd, addrOf(unsafe.Pointer(path)), mode, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepOpenatDirect prepares an openat into registered file slot fileIndex,
+// or IORING_FILE_INDEX_ALLOC.
+func PrepOpenatDirect(sqe *IoUringSqe, dfd int32, path *byte, flags uint32, mode uint32, fileIndex uint32) {
+	PrepOpenat(sqe, dfd, path, flags, mode)
+	setTargetFixedFile(sqe, fileIndex)
+}
+
+// PrepOpenat2 prepares an openat2.
+func PrepOpenat2(sqe *IoUringSqe, dfd int32, path *byte, how *OpenHow) {
+	prepRW(sqe, IORING_OP_OPENAT2, dfd, addrOf(unsafe.Pointer(path)), uint32(unsafe.Sizeof(*how)), addrOf(unsafe.Pointer(how)))
+}
+
+// PrepClose prepares a close.
+func PrepClose(sqe *IoUringSqe, fd int32) {
+	prepRW(sqe, IORING_OP_CLOSE, fd, 0, 0, 0)
+}
+
+// PrepCloseDirect prepares the removal of registered file slot fileIndex.
+func PrepCloseDirect(sqe *IoUringSqe, fileIndex uint32) {
+	prepRW(sqe, IORING_OP_CLOSE, 0, 0, 0, 0)
+	setTargetFixedFile(sqe, fileIndex)
+}
+
+// PrepStatx prepares a statx into statxbuf.
+func PrepStatx(sqe *IoUringSqe, dfd int32, path *byte, flags uint32, mask uint32, statxbuf unsafe.Pointer) {
+	prepRW(sqe, IORING_OP_STATX, dfd, addrOf(unsafe.Pointer(path)), mask, addrOf(statxbuf))
+	sqe.OpFlags = flags
+}
+
+// PrepRenameat prepares a renameat2.
+func PrepRenameat(sqe *IoUringSqe, oldDfd int32, oldPath *byte, newDfd int32, newPath *byte, flags uint32) {
+	prepRW(sqe, IORING_OP_RENAMEAT, oldDfd, addrOf(unsafe.Pointer(oldPath)), uint32(newDfd), addrOf(unsafe.Pointer(newPath)))
+	sqe.OpFlags = flags
+}
+
+// PrepUnlinkat prepares an unlinkat. Flags may include AT_REMOVEDIR.
+func PrepUnlinkat(sqe *IoUringSqe, dfd int32, path *byte, flags uint32) {
+	prepRW(sqe, IORING_OP_UNLINKAT, dfd, addrOf(unsafe.Pointer(path)), 0, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepMkdirat prepares a mkdirat.
+func PrepMkdirat(sqe *IoUringSqe, dfd int32, path *byte, mode uint32) {
+	prepRW(sqe, IORING_OP_MKDIRAT, dfd, addrOf(unsafe.Pointer(path)), mode, 0)
+}
+
+// PrepSymlinkat prepares a symlinkat creating linkpath pointing to target.
+func PrepSymlinkat(sqe *IoUringSqe, target *byte, newDfd int32, linkpath *byte) {
+	prepRW(sqe, IORING_OP_SYMLINKAT, newDfd, addrOf(unsafe.Pointer(target)), 0, addrOf(unsafe.Pointer(linkpath)))
+}
+
+// PrepLinkat prepares a linkat.
+func PrepLinkat(sqe *IoUringSqe, oldDfd int32, oldPath *byte, newDfd int32, newPath *byte, flags uint32) {
+	prepRW(sqe, IORING_OP_LINKAT, oldDfd, addrOf(unsafe.Pointer(oldPath)), uint32(newDfd), addrOf(unsafe.Pointer(newPath)))
+	sqe.OpFlags = flags
+}
+
+// PrepFsetxattr prepares an fsetxattr of name to value.
+func PrepFsetxattr(sqe *IoUringSqe, fd int32, name *byte, value []byte, flags uint32) {
+	prepRW(sqe, IORING_OP_FSETXATTR, fd, addrOf(unsafe.Pointer(name)), uint32(len(value)), sliceAddr(value))
+	sqe.OpFlags = flags
+}
+
+// PrepSetxattr prepares a setxattr of name to value on path.
+func PrepSetxattr(sqe *IoUringSqe, path, name *byte, value []byte, flags uint32) {
+	prepRW(sqe, IORING_OP_SETXATTR, 0, addrOf(unsafe.Pointer(name)), uint32(len(value)), sliceAddr(value))
+	sqe.Addr3 = addrOf(unsafe.Pointer(path))
+	sqe.OpFlags = flags
+}
+
+// PrepFgetxattr prepares an fgetxattr of name into value.
+func PrepFgetxattr(sqe *IoUringSqe, fd int32, name *byte, value []byte) {
+	prepRW(sqe, IORING_OP_FGETXATTR, fd, addrOf(unsafe.Pointer(name)), uint32(len(value)), sliceAddr(value))
+}
+
+// PrepGetxattr prepares a getxattr of name on path into value.
+func PrepGetxattr(sqe *IoUringSqe, path, name *byte, value []byte) {
+	prepRW(sqe, IORING_OP_GETXATTR, 0, addrOf(unsafe.Pointer(name)), uint32(len(value)), sliceAddr(value))
+	sqe.Addr3 = addrOf(unsafe.Pointer(path))
+}
+
+// PrepSplice prepares a splice of nbytes from fdIn to fdOut.
+// An offset of -1 uses the current file position, as required for pipes.
+// Flags may include SPLICE_F_FD_IN_FIXED when fdIn is a registered file.
+func PrepSplice(sqe *IoUringSqe, fdIn int32, offIn int64, fdOut int32, offOut int64, nbytes uint32, flags uint32) {
+	prepRW(sqe, IORING_OP_SPLICE, fdOut, uint64(offIn), nbytes, uint64(offOut))
+	sqe.FileIndex = uint32(fdIn)
+	sqe.OpFlags = flags
+}
+
+// PrepTee prepares a tee of nbytes from pipe fdIn to pipe fdOut.
+func PrepTee(sqe *IoUringSqe, fdIn, fdOut int32, nbytes uint32, flags uint32) {
+	prepRW(sqe, IORING_OP_TEE, fdOut, 0, nbytes, 0)
+	sqe.FileIndex = uint32(fdIn)
+	sqe.OpFlags = flags
+}
+
+// PrepPipe prepares a pipe2 storing the new descriptors in fds.
+func PrepPipe(sqe *IoUringSqe, fds *[2]int32, flags uint32) {
+	prepRW(sqe, IORING_OP_PIPE, 0, addrOf(unsafe.Pointer(fds)), 0, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepEpollCtl prepares an epoll_ctl. ev points to an epoll_event.
+func PrepEpollCtl(sqe *IoUringSqe, epfd, fd int32, op uint32, ev unsafe.Pointer) {
+	prepRW(sqe, IORING_OP_EPOLL_CTL, epfd, addrOf(ev), op, uint64(fd))
+}
+
+// PrepEpollWait prepares an epoll_wait into an array of maxEvents epoll_event.
+func PrepEpollWait(sqe *IoUringSqe, epfd int32, events unsafe.Pointer, maxEvents uint32, flags uint32) {
+	prepRW(sqe, IORING_OP_EPOLL_WAIT, epfd, addrOf(events), maxEvents, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepProvideBuffers prepares the provision of nr buffers of bufLen bytes,
+// laid out contiguously from addr, to buffer group bgid starting at buffer
+// id bid.
+func PrepProvideBuffers(sqe *IoUringSqe, addr unsafe.Pointer, bufLen uint32, nr uint32, bgid, bid uint16) {
+	prepRW(sqe, IORING_OP_PROVIDE_BUFFERS, int32(nr), addrOf(addr), bufLen, uint64(bid))
+	sqe.BufIndex = bgid
+}
+
+// PrepRemoveBuffers prepares the removal of up to nr buffers from buffer group bgid.
+func PrepRemoveBuffers(sqe *IoUringSqe, nr uint32, bgid uint16) {
+	prepRW(sqe, IORING_OP_REMOVE_BUFFERS, int32(nr), 0, 0, 0)
+	sqe.BufIndex = bgid
+}
+
+// PrepFilesUpdate prepares an update of nrFds registered files starting at
+// offset with the descriptors in fds.
+func PrepFilesUpdate(sqe *IoUringSqe, fds *int32, nrFds uint32, offset int32) {
+	prepRW(sqe, IORING_OP_FILES_UPDATE, -1, addrOf(unsafe.Pointer(fds)), nrFds, uint64(offset))
+}
+
+// PrepFixedFdInstall prepares the installation of registered file fd
+// into the regular file descriptor table.
+func PrepFixedFdInstall(sqe *IoUringSqe, fd int32, flags uint32) {
+	prepRW(sqe, IORING_OP_FIXED_FD_INSTALL, fd, 0, 0, 0)
+	sqe.Flags = IOSQE_FIXED_FILE
+	sqe.OpFlags = flags
+}
+
+// PrepMsgRing prepares a message to the ring fd, posting a completion
+// with res set to length and user data set to data.
+func PrepMsgRing(sqe *IoUringSqe, fd int32, length uint32, data uint64, flags uint32) {
+	prepRW(sqe, IORING_OP_MSG_RING, fd, IORING_MSG_DATA, length, data)
+	sqe.OpFlags = flags
+}
+
+// PrepMsgRingCQEFlags is PrepMsgRing that also sets the flags of the
+// posted completion to cqeFlags (IORING_MSG_RING_FLAGS_PASS).
+func PrepMsgRingCQEFlags(sqe *IoUringSqe, fd int32, length uint32, data uint64, flags uint32, cqeFlags uint32) {
+	PrepMsgRing(sqe, fd, length, data, flags|IORING_MSG_RING_FLAGS_PASS)
+	sqe.FileIndex = cqeFlags
+}
+
+// PrepMsgRingFd prepares the transfer of registered file sourceFd of the
+// submitting ring into registered file slot targetIndex of the ring fd,
+// or into a free slot of its allocation range with IORING_FILE_INDEX_ALLOC.
+// Unless flags include IORING_MSG_RING_CQE_SKIP, the target ring receives
+// a completion with user data set to data.
+func PrepMsgRingFd(sqe *IoUringSqe, fd int32, sourceFd uint32, targetIndex uint32, data uint64, flags uint32) {
+	prepRW(sqe, IORING_OP_MSG_RING, fd, IORING_MSG_SEND_FD, 0, data)
+	sqe.Addr3 = uint64(sourceFd)
+	sqe.OpFlags = flags
+	setTargetFixedFile(sqe, targetIndex)
+}
+
+// PrepWaitid prepares a waitid. infop points to a siginfo_t and may be nil.
+func PrepWaitid(sqe *IoUringSqe, idtype, id uint32, infop unsafe.Pointer, options, flags uint32) {
+	prepRW(sqe, IORING_OP_WAITID, int32(id), 0, idtype, addrOf(infop))
+	sqe.OpFlags = flags
+	sqe.FileIndex = options
+}
+
+// PrepFutexWait prepares a futex wait on futex while it holds val.
+// futexFlags are FUTEX2_* flags; flags must currently be 0.
+func PrepFutexWait(sqe *IoUringSqe, futex *uint32, val, mask uint64, futexFlags, flags uint32) {
+	prepRW(sqe, IORING_OP_FUTEX_WAIT, int32(futexFlags), addrOf(unsafe.Pointer(futex)), 0, val)
+	sqe.Addr3 = mask
+	sqe.OpFlags = flags
+}
+
+// PrepFutexWake prepares a wake of up to val waiters on futex.
+// futexFlags are FUTEX2_* flags; flags must currently be 0.
+func PrepFutexWake(sqe *IoUringSqe, futex *uint32, val, mask uint64, futexFlags, flags uint32) {
+	prepRW(sqe, IORING_OP_FUTEX_WAKE, int32(futexFlags), addrOf(unsafe.Pointer(futex)), 0, val)
+	sqe.Addr3 = mask
+	sqe.OpFlags = flags
+}
+
+// PrepFutexWaitv prepares a wait on nrFutex futexes described by an array
+// of FutexWaiter entries. The completion result is the index of the woken
+// futex.
+func PrepFutexWaitv(sqe *IoUringSqe, futexv unsafe.Pointer, nrFutex uint32, flags uint32) {
+	prepRW(sqe, IORING_OP_FUTEX_WAITV, 0, addrOf(futexv), nrFutex, 0)
+	sqe.OpFlags = flags
+}
+
+// PrepUringCmd prepares a file-specific command cmdOp on fd.
+// Command arguments are written to sqe.Cmd().
+func PrepUringCmd(sqe *IoUringSqe, cmdOp uint32, fd int32) {
+	prepRW(sqe, IORING_OP_URING_CMD, fd, 0, 0, uint64(cmdOp))
+}
+
+// PrepCmdSock prepares the socket command cmdOp, one of the
+// SOCKET_URING_OP_* values, on the socket fd. level, optname, optval, and
+// optlen are the getsockopt and setsockopt arguments; the other commands
+// take none of them.
+func PrepCmdSock(sqe *IoUringSqe, cmdOp uint32, fd int32, level, optname uint32, optval unsafe.Pointer, optlen uint32) {
+	prepRW(sqe, IORING_OP_URING_CMD, fd, uint64(level)|uint64(optname)<<32, 0, uint64(cmdOp))
+	sqe.FileIndex = optlen
+	sqe.Addr3 = addrOf(optval)
+}
+
+// PrepSiocInq prepares a query of the bytes queued for reading on the
+// socket fd, as with the SIOCINQ ioctl. The count is the completion result.
+func PrepSiocInq(sqe *IoUringSqe, fd int32) {
+	PrepCmdSock(sqe, SOCKET_URING_OP_SIOCINQ, fd, 0, 0, nil, 0)
+}
+
+// PrepSiocOutq prepares a query of the bytes not yet sent or acknowledged
+// on the socket fd, as with the SIOCOUTQ ioctl. The count is the completion
+// result.
+func PrepSiocOutq(sqe *IoUringSqe, fd int32) {
+	PrepCmdSock(sqe, SOCKET_URING_OP_SIOCOUTQ, fd, 0, 0, nil, 0)
+}
+
+// PrepGetsockopt prepares a getsockopt on the socket fd. The kernel only
+// supports SOL_SOCKET options here. The completion result is the length of
+// the value written to optval.
+func PrepGetsockopt(sqe *IoUringSqe, fd int32, level, optname uint32, optval unsafe.Pointer, optlen uint32) {
+	PrepCmdSock(sqe, SOCKET_URING_OP_GETSOCKOPT, fd, level, optname, optval, optlen)
+}
+
+// PrepSetsockopt prepares a setsockopt on the socket fd.
+func PrepSetsockopt(sqe *IoUringSqe, fd int32, level, optname uint32, optval unsafe.Pointer, optlen uint32) {
+	PrepCmdSock(sqe, SOCKET_URING_OP_SETSOCKOPT, fd, level, optname, optval, optlen)
+}
+
+// PrepTxTimestamp prepares a collection of the transmit timestamps queued
+// on the error queue of the socket fd, which must have SO_TIMESTAMPING
+// enabled. It requires a ring created with IORING_SETUP_CQE32: each
+// timestamp is posted as a 32-byte completion with IORING_CQE_F_MORE whose
+// BigCqe holds the seconds and nanoseconds, IORING_CQE_F_TSTAMP_HW marks a
+// hardware timestamp, and the bits from IORING_TIMESTAMP_TYPE_SHIFT hold
+// the SCM_TSTAMP_* type. The final completion has no IORING_CQE_F_MORE.
+func PrepTxTimestamp(sqe *IoUringSqe, fd int32) {
+	PrepCmdSock(sqe, SOCKET_URING_OP_TX_TIMESTAMP, fd, 0, 0, nil, 0)
+}
+
+// PrepUringCmd128 prepares a file-specific command cmdOp on fd with a
+// 128-byte SQE. Command arguments are written to the IoUringSqe128 Cmd area.
+// It is only valid in a ring created with IORING_SETUP_SQE_MIXED, on an
+// entry obtained from SubmissionQueue.NextSQE128.
+func PrepUringCmd128(sqe *IoUringSqe, cmdOp uint32, fd int32) {
+	prepRW(sqe, IORING_OP_URING_CMD128, fd, 0, 0, uint64(cmdOp))
+}
@@ -0,0 +1,945 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// cstr returns a NUL-terminated copy of s.
+func cstr(s string) *byte {
+	b := append([]byte(s), 0)
+	return &b[0]
+}
+
+func testPipe(t *testing.T) (r, w int32) {
+	t.Helper()
+	var fds [2]int32
+	if errno := zcall.Pipe2(&fds, zcall.O_CLOEXEC); errno != 0 {
+		t.Fatalf("Pipe2 failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() {
+		zcall.Close(uintptr(fds[0]))
+		zcall.Close(uintptr(fds[1]))
+	})
+	return fds[0], fds[1]
+}
+
+func testSocketpair(t *testing.T) (a, b int32) {
+	t.Helper()
+	var fds [2]int32
+	if errno := zcall.Socketpair(zcall.AF_UNIX, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0, &fds); errno != 0 {
+		t.Fatalf("Socketpair failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() {
+		zcall.Close(uintptr(fds[0]))
+		zcall.Close(uintptr(fds[1]))
+	})
+	return fds[0], fds[1]
+}
+
+// testTCPPair returns a connected pair of loopback TCP sockets.
+func testTCPPair(t *testing.T) (a, b int32) {
+	t.Helper()
+	lfd, errno := zcall.Socket(zcall.AF_INET, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0)
+	if errno != 0 {
+		t.Fatalf("Socket failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Close(lfd)
+	addr := [16]byte{2, 0, 0, 0, 127, 0, 0, 1}
+	addrLen := uint32(16)
+	if errno := zcall.Bind(lfd, unsafe.Pointer(&addr), 16); errno != 0 {
+		t.Fatalf("Bind failed: %v", zcall.Errno(errno))
+	}
+	zcall.Listen(lfd, 1)
+	zcall.Getsockname(lfd, unsafe.Pointer(&addr), unsafe.Pointer(&addrLen))
+	cfd, errno := zcall.Socket(zcall.AF_INET, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0)
+	if errno != 0 {
+		t.Fatalf("Socket failed: %v", zcall.Errno(errno))
+	}
+	if errno := zcall.Connect(cfd, unsafe.Pointer(&addr), 16); errno != 0 {
+		zcall.Close(cfd)
+		t.Fatalf("Connect failed: %v", zcall.Errno(errno))
+	}
+	afd, errno := zcall.Accept4(lfd, nil, nil, zcall.SOCK_CLOEXEC)
+	if errno != 0 {
+		zcall.Close(cfd)
+		t.Fatalf("Accept4 failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() {
+		zcall.Close(cfd)
+		zcall.Close(afd)
+	})
+	return int32(cfd), int32(afd)
+}
+
+func testFile(t *testing.T) (*os.File, int32) {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "prep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f, int32(f.Fd())
+}
+
+// expectRes fails the test unless cqe.Res equals want.
+func expectRes(t *testing.T, name string, cqe zcall.IoUringCqe, want int32) {
+	t.Helper()
+	if cqe.Res != want {
+		if cqe.Res < 0 {
+			t.Fatalf("%s: res = %d (%v), want %d", name, cqe.Res, zcall.Errno(-cqe.Res), want)
+		}
+		t.Fatalf("%s: res = %d, want %d", name, cqe.Res, want)
+	}
+}
+
+// skipIfUnsupported skips the test when the kernel rejected a newer opcode.
+func skipIfUnsupported(t *testing.T, name string, cqe zcall.IoUringCqe) {
+	t.Helper()
+	if cqe.Res == -int32(zcall.EINVAL) || cqe.Res == -int32(zcall.EOPNOTSUPP) {
+		t.Skipf("%s not supported on this kernel", name)
+	}
+}
+
+func TestIoUringSqeLayout(t *testing.T) {
+	var sqe zcall.IoUringSqe
+	sizes := []struct {
+		name      string
+		got, want uintptr
+	}{
+		{"sizeof(IoUringSqe)", unsafe.Sizeof(sqe), 64},
+		{"sizeof(IoUringSqe128)", unsafe.Sizeof(zcall.IoUringSqe128{}), 128},
+		{"sizeof(IoUringCqe)", unsafe.Sizeof(zcall.IoUringCqe{}), 16},
+		{"sizeof(IoUringCqe32)", unsafe.Sizeof(zcall.IoUringCqe32{}), 32},
+		{"offsetof(Off)", unsafe.Offsetof(sqe.Off), 8},
+		{"offsetof(Addr)", unsafe.Offsetof(sqe.Addr), 16},
+		{"offsetof(Len)", unsafe.Offsetof(sqe.Len), 24},
+		{"offsetof(OpFlags)", unsafe.Offsetof(sqe.OpFlags), 28},
+		{"offsetof(UserData)", unsafe.Offsetof(sqe.UserData), 32},
+		{"offsetof(BufIndex)", unsafe.Offsetof(sqe.BufIndex), 40},
+		{"offsetof(Personality)", unsafe.Offsetof(sqe.Personality), 42},
+		{"offsetof(FileIndex)", unsafe.Offsetof(sqe.FileIndex), 44},
+		{"offsetof(Addr3)", unsafe.Offsetof(sqe.Addr3), 48},
+		{"sizeof(OpenHow)", unsafe.Sizeof(zcall.OpenHow{}), 24},
+	}
+	for _, s := range sizes {
+		if s.got != s.want {
+			t.Errorf("%s = %d, want %d", s.name, s.got, s.want)
+		}
+	}
+	if got := uintptr(unsafe.Pointer(sqe.Cmd())) - uintptr(unsafe.Pointer(&sqe)); got != 48 {
+		t.Errorf("Cmd offset = %d, want 48", got)
+	}
+}
+
+func TestPrepResetsEntry(t *testing.T) {
+	sqe := zcall.IoUringSqe{UserData: 99, Flags: zcall.IOSQE_IO_LINK, Personality: 3, Addr3: 7}
+	zcall.PrepNop(&sqe)
+	want := zcall.IoUringSqe{Opcode: zcall.IORING_OP_NOP, Fd: -1}
+	if sqe != want {
+		t.Fatalf("PrepNop left stale fields: %+v", sqe)
+	}
+}
+
+func TestPrepNop(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	r.push(0xdeadbeef, zcall.PrepNop)
+	r.enter(1)
+	cqe, ok := r.pop()
+	if !ok {
+		t.Fatal("no completion")
+	}
+	expectRes(t, "NOP", cqe, 0)
+	if cqe.UserData != 0xdeadbeef {
+		t.Fatalf("user data = %#x, want 0xdeadbeef", cqe.UserData)
+	}
+}
+
+// newMixedRing creates a 4-entry IORING_SETUP_SQE_MIXED ring, skipping the
+// test when the kernel does not support it.
+func newMixedRing(t *testing.T) *testRing {
+	t.Helper()
+	p := zcall.IoUringParams{Flags: zcall.IORING_SETUP_SQE_MIXED}
+	fd, errno := zcall.IoUringSetup(4, unsafe.Pointer(&p))
+	if errno != 0 {
+		t.Skipf("IORING_SETUP_SQE_MIXED not supported: %v", zcall.Errno(errno))
+	}
+	zcall.Close(fd)
+	p = zcall.IoUringParams{Flags: zcall.IORING_SETUP_SQE_MIXED}
+	return newTestRingParams(t, 4, &p)
+}
+
+func TestPrepNop128(t *testing.T) {
+	if newTestRing(t, 4, zcall.IORING_SETUP_SQE128).sq.NextSQE128() != nil {
+		t.Fatal("NextSQE128 returned an entry in a ring without IORING_SETUP_SQE_MIXED")
+	}
+	r := newMixedRing(t)
+	nop128 := func(userData uint64) {
+		t.Helper()
+		sqe := r.sq.NextSQE128()
+		if sqe == nil {
+			t.Fatal("NextSQE128 returned nil")
+		}
+		zcall.PrepNop128(&sqe.IoUringSqe)
+		sqe.UserData = userData
+	}
+
+	r.push(1, zcall.PrepNop)
+	nop128(2)
+	r.enter(2)
+	expectRes(t, "NOP", r.next(), 0)
+	cqe := r.next()
+	expectRes(t, "NOP128", cqe, 0)
+	if cqe.UserData != 2 {
+		t.Fatalf("NOP128 user data = %d, want 2", cqe.UserData)
+	}
+
+	// The last slot is padded and the entry wraps to the start of the ring.
+	nop128(3)
+	r.enter(1)
+	cqe = r.next()
+	expectRes(t, "wrapped NOP128", cqe, 0)
+	if cqe.UserData != 3 {
+		t.Fatalf("wrapped NOP128 user data = %d, want 3", cqe.UserData)
+	}
+	if _, ok := r.pop(); ok {
+		t.Fatal("padding NOP posted a completion")
+	}
+
+	nop128(4)
+	nop128(5)
+	if r.sq.NextSQE128() != nil {
+		t.Fatal("NextSQE128 returned an entry from a full queue")
+	}
+	r.enter(2)
+	expectRes(t, "NOP128", r.next(), 0)
+	expectRes(t, "NOP128", r.next(), 0)
+}
+
+func TestPrepUringCmd128(t *testing.T) {
+	r := newMixedRing(t)
+	a, _ := testSocketpair(t)
+	sqe := r.sq.NextSQE128()
+	if sqe == nil {
+		t.Fatal("NextSQE128 returned nil")
+	}
+	zcall.PrepUringCmd128(&sqe.IoUringSqe, zcall.SOCKET_URING_OP_SIOCINQ, a)
+	r.enter(1)
+	cqe := r.next()
+	skipIfUnsupported(t, "URING_CMD128", cqe)
+	expectRes(t, "URING_CMD128 SIOCINQ", cqe, 0)
+}
+
+func TestPrepFileIO(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	f, fd := testFile(t)
+
+	data := []byte("hello world")
+	expectRes(t, "WRITE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepWrite(sqe, fd, data, 0) }), 11)
+	buf := make([]byte, 5)
+	expectRes(t, "READ", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, fd, buf, 6) }), 5)
+	if string(buf) != "world" {
+		t.Fatalf("READ data = %q, want %q", buf, "world")
+	}
+
+	a, b := []byte("-vec"), []byte("tor")
+	wiov := []zcall.Iovec{{Base: &a[0], Len: uint64(len(a))}, {Base: &b[0], Len: uint64(len(b))}}
+	expectRes(t, "WRITEV", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWritev(sqe, fd, unsafe.Pointer(&wiov[0]), 2, 11)
+	}), 7)
+	ra, rb := make([]byte, 5), make([]byte, 13)
+	riov := []zcall.Iovec{{Base: &ra[0], Len: uint64(len(ra))}, {Base: &rb[0], Len: uint64(len(rb))}}
+	expectRes(t, "READV", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepReadv(sqe, fd, unsafe.Pointer(&riov[0]), 2, 0)
+	}), 18)
+	if got := string(ra) + string(rb); got != "hello world-vector" {
+		t.Fatalf("READV data = %q", got)
+	}
+
+	expectRes(t, "FSYNC", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFsync(sqe, fd, 0) }), 0)
+	expectRes(t, "FSYNC datasync", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepFsync(sqe, fd, zcall.IORING_FSYNC_DATASYNC)
+	}), 0)
+	expectRes(t, "SYNC_FILE_RANGE", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSyncFileRange(sqe, fd, 18, 0, zcall.SYNC_FILE_RANGE_WRITE)
+	}), 0)
+	expectRes(t, "FADVISE", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepFadvise(sqe, fd, 0, 18, zcall.POSIX_FADV_SEQUENTIAL)
+	}), 0)
+
+	expectRes(t, "FALLOCATE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFallocate(sqe, fd, 0, 0, 4096) }), 0)
+	if st, err := f.Stat(); err != nil {
+		t.Fatal(err)
+	} else if st.Size() != 4096 {
+		t.Fatalf("size after FALLOCATE = %d, want 4096", st.Size())
+	}
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFtruncate(sqe, fd, 5) })
+	skipIfUnsupported(t, "FTRUNCATE", cqe)
+	expectRes(t, "FTRUNCATE", cqe, 0)
+	if st, err := f.Stat(); err != nil {
+		t.Fatal(err)
+	} else if st.Size() != 5 {
+		t.Fatalf("size after FTRUNCATE = %d, want 5", st.Size())
+	}
+}
+
+func TestPrepFixedBuffers(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	_, fd := testFile(t)
+
+	mem, errno := zcall.Mmap(nil, 4096, zcall.PROT_READ|zcall.PROT_WRITE, zcall.MAP_PRIVATE|zcall.MAP_ANONYMOUS, ^uintptr(0), 0)
+	if errno != 0 {
+		t.Fatalf("Mmap failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Munmap(mem, 4096)
+	buf := unsafe.Slice((*byte)(mem), 4096)
+	iov := zcall.Iovec{Base: &buf[0], Len: 4096}
+	if _, errno := zcall.IoUringRegister(r.fd, zcall.IORING_REGISTER_BUFFERS, unsafe.Pointer(&iov), 1); errno != 0 {
+		t.Fatalf("IORING_REGISTER_BUFFERS failed: %v", zcall.Errno(errno))
+	}
+
+	copy(buf, "fixed")
+	expectRes(t, "WRITE_FIXED", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepWriteFixed(sqe, fd, buf[:5], 0, 0) }), 5)
+	expectRes(t, "READ_FIXED", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepReadFixed(sqe, fd, buf[100:105], 0, 0) }), 5)
+	if string(buf[100:105]) != "fixed" {
+		t.Fatalf("READ_FIXED data = %q", buf[100:105])
+	}
+
+	copy(buf[200:], "vectored")
+	wiov := []zcall.Iovec{{Base: &buf[200], Len: 3}, {Base: &buf[203], Len: 5}}
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepWritevFixed(sqe, fd, unsafe.Pointer(&wiov[0]), 2, 5, 0) })
+	skipIfUnsupported(t, "WRITEV_FIXED", cqe)
+	expectRes(t, "WRITEV_FIXED", cqe, 8)
+	riov := []zcall.Iovec{{Base: &buf[300], Len: 13}}
+	expectRes(t, "READV_FIXED", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepReadvFixed(sqe, fd, unsafe.Pointer(&riov[0]), 1, 0, 0)
+	}), 13)
+	if string(buf[300:313]) != "fixedvectored" {
+		t.Fatalf("READV_FIXED data = %q", buf[300:313])
+	}
+}
+
+func TestPrepPoll(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, wfd := testPipe(t)
+
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepPollAdd(sqe, rfd, zcall.POLLIN) })
+	r.enter(0)
+	if _, ok := r.pop(); ok {
+		t.Fatal("POLL_ADD completed before the pipe was readable")
+	}
+	zcall.Write(uintptr(wfd), []byte("x"))
+	r.enter(1)
+	cqe, ok := r.pop()
+	if !ok || cqe.UserData != 1 || cqe.Res&zcall.POLLIN == 0 {
+		t.Fatalf("POLL_ADD completion = %+v, %v", cqe, ok)
+	}
+
+	wfd2, _ := testPipe(t)
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepPollAdd(sqe, wfd2, zcall.POLLIN) })
+	r.push(3, func(sqe *zcall.IoUringSqe) { zcall.PrepPollRemove(sqe, 2) })
+	r.enter(2)
+	for range 2 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 2:
+			expectRes(t, "cancelled POLL_ADD", cqe, -int32(zcall.ECANCELED))
+		case 3:
+			expectRes(t, "POLL_REMOVE", cqe, 0)
+		default:
+			t.Fatalf("unexpected completion %+v", cqe)
+		}
+	}
+}
+
+func TestPrepTimeout(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+
+	ts := zcall.Timespec{Nsec: 1e6}
+	expectRes(t, "TIMEOUT", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepTimeout(sqe, &ts, 0, 0) }), -int32(zcall.ETIME))
+
+	long := zcall.Timespec{Sec: 10}
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepTimeout(sqe, &long, 0, 0) })
+	r.push(3, func(sqe *zcall.IoUringSqe) { zcall.PrepTimeoutRemove(sqe, 2, 0) })
+	r.enter(2)
+	for range 2 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 2:
+			expectRes(t, "removed TIMEOUT", cqe, -int32(zcall.ECANCELED))
+		case 3:
+			expectRes(t, "TIMEOUT_REMOVE", cqe, 0)
+		default:
+			t.Fatalf("unexpected completion %+v", cqe)
+		}
+	}
+}
+
+func TestPrepLinkTimeout(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+
+	ts := zcall.Timespec{Nsec: 1e6}
+	r.push(1, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepPollAdd(sqe, rfd, zcall.POLLIN)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	})
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepLinkTimeout(sqe, &ts, 0) })
+	r.enter(2)
+	for range 2 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 1:
+			expectRes(t, "timed out POLL_ADD", cqe, -int32(zcall.ECANCELED))
+		case 2:
+			expectRes(t, "LINK_TIMEOUT", cqe, -int32(zcall.ETIME))
+		default:
+			t.Fatalf("unexpected completion %+v", cqe)
+		}
+	}
+}
+
+func TestPrepCancel(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, _ := testPipe(t)
+
+	r.push(5, func(sqe *zcall.IoUringSqe) { zcall.PrepPollAdd(sqe, rfd, zcall.POLLIN) })
+	r.push(6, func(sqe *zcall.IoUringSqe) { zcall.PrepCancel(sqe, 5, 0) })
+	r.enter(2)
+	for range 2 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 5:
+			expectRes(t, "cancelled POLL_ADD", cqe, -int32(zcall.ECANCELED))
+		case 6:
+			expectRes(t, "ASYNC_CANCEL", cqe, 0)
+		default:
+			t.Fatalf("unexpected completion %+v", cqe)
+		}
+	}
+}
+
+func TestPrepSocketLifecycle(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+
+	lcqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSocket(sqe, zcall.AF_INET, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0, 0)
+	})
+	if lcqe.Res < 0 {
+		t.Fatalf("SOCKET failed: %v", zcall.Errno(-lcqe.Res))
+	}
+	lfd := lcqe.Res
+	defer zcall.Close(uintptr(lfd))
+
+	addr := [16]byte{2, 0, 0, 0, 127, 0, 0, 1}
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepBind(sqe, lfd, unsafe.Pointer(&addr), 16) })
+	skipIfUnsupported(t, "BIND", cqe)
+	expectRes(t, "BIND", cqe, 0)
+	expectRes(t, "LISTEN", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepListen(sqe, lfd, 16) }), 0)
+
+	var bound [16]byte
+	boundLen := uint32(16)
+	if errno := zcall.Getsockname(uintptr(lfd), unsafe.Pointer(&bound), unsafe.Pointer(&boundLen)); errno != 0 {
+		t.Fatalf("Getsockname failed: %v", zcall.Errno(errno))
+	}
+	cfd, errno := zcall.Socket(zcall.AF_INET, zcall.SOCK_STREAM|zcall.SOCK_CLOEXEC, 0)
+	if errno != 0 {
+		t.Fatalf("Socket failed: %v", zcall.Errno(errno))
+	}
+
+	var peer [16]byte
+	peerLen := uint32(16)
+	r.push(1, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepAccept(sqe, lfd, unsafe.Pointer(&peer), unsafe.Pointer(&peerLen), zcall.SOCK_CLOEXEC)
+	})
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepConnect(sqe, int32(cfd), unsafe.Pointer(&bound), 16) })
+	r.enter(2)
+	var afd int32 = -1
+	for range 2 {
+		cqe := r.next()
+		switch cqe.UserData {
+		case 1:
+			if cqe.Res < 0 {
+				t.Fatalf("ACCEPT failed: %v", zcall.Errno(-cqe.Res))
+			}
+			afd = cqe.Res
+		case 2:
+			expectRes(t, "CONNECT", cqe, 0)
+		}
+	}
+	if peerLen != 16 || peer[0] != 2 {
+		t.Fatalf("ACCEPT peer address = %v (len %d)", peer, peerLen)
+	}
+
+	expectRes(t, "SHUTDOWN", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepShutdown(sqe, afd, zcall.SHUT_RDWR) }), 0)
+	expectRes(t, "CLOSE accepted", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepClose(sqe, afd) }), 0)
+	expectRes(t, "CLOSE client", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepClose(sqe, int32(cfd)) }), 0)
+}
+
+func TestPrepSendRecv(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testSocketpair(t)
+
+	msg := []byte("ping")
+	expectRes(t, "SEND", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepSend(sqe, a, msg, 0) }), 4)
+	buf := make([]byte, 16)
+	expectRes(t, "RECV", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepRecv(sqe, b, buf, 0) }), 4)
+	if string(buf[:4]) != "ping" {
+		t.Fatalf("RECV data = %q", buf[:4])
+	}
+
+	siov := zcall.Iovec{Base: &msg[0], Len: uint64(len(msg))}
+	smsg := zcall.Msghdr{Iov: &siov, Iovlen: 1}
+	expectRes(t, "SENDMSG", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepSendmsg(sqe, a, &smsg, 0) }), 4)
+	riov := zcall.Iovec{Base: &buf[0], Len: uint64(len(buf))}
+	rmsg := zcall.Msghdr{Iov: &riov, Iovlen: 1}
+	expectRes(t, "RECVMSG", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepRecvmsg(sqe, b, &rmsg, 0) }), 4)
+}
+
+func TestPrepSendZC(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testTCPPair(t)
+	msg := []byte("zero-copy")
+
+	// Each zero-copy send posts a result and then a notification.
+	check := func(name string) {
+		t.Helper()
+		r.enter(2)
+		res := r.next()
+		skipIfUnsupported(t, name, res)
+		expectRes(t, name, res, int32(len(msg)))
+		notif, ok := r.pop()
+		if !ok || notif.UserData != res.UserData {
+			t.Fatalf("%s: missing notification completion", name)
+		}
+		buf := make([]byte, 16)
+		n, errno := zcall.Read(uintptr(b), buf)
+		if errno != 0 || string(buf[:n]) != "zero-copy" {
+			t.Fatalf("%s: peer read %q, %v", name, buf[:n], zcall.Errno(errno))
+		}
+	}
+
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepSendZC(sqe, a, msg, 0, 0) })
+	check("SEND_ZC")
+	iov := zcall.Iovec{Base: &msg[0], Len: uint64(len(msg))}
+	m := zcall.Msghdr{Iov: &iov, Iovlen: 1}
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepSendmsgZC(sqe, a, &m, 0) })
+	check("SENDMSG_ZC")
+}
+
+func TestPrepRecvZC(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	_, b := testSocketpair(t)
+	// Without a registered zcrx interface queue the request must be rejected.
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepRecvZC(sqe, b, 0, 0, 0) })
+	if cqe.Res >= 0 {
+		t.Fatalf("RECV_ZC without an interface queue succeeded: res = %d", cqe.Res)
+	}
+}
+
+func TestPrepPathOps(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepOpenat(sqe, zcall.AT_FDCWD, cstr(path), zcall.O_RDWR|zcall.O_CREAT|zcall.O_CLOEXEC, 0o644)
+	})
+	if cqe.Res < 0 {
+		t.Fatalf("OPENAT failed: %v", zcall.Errno(-cqe.Res))
+	}
+	zcall.Write(uintptr(cqe.Res), []byte("abc"))
+	expectRes(t, "CLOSE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepClose(sqe, cqe.Res) }), 0)
+
+	how := zcall.OpenHow{Flags: zcall.O_RDONLY | zcall.O_CLOEXEC, Resolve: zcall.RESOLVE_NO_SYMLINKS}
+	cqe = r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepOpenat2(sqe, zcall.AT_FDCWD, cstr(path), &how) })
+	if cqe.Res < 0 {
+		t.Fatalf("OPENAT2 failed: %v", zcall.Errno(-cqe.Res))
+	}
+	zcall.Close(uintptr(cqe.Res))
+
+	// statx: stx_mask is at offset 0 and stx_size at offset 40.
+	const statxSize = 0x200
+	var stx [256]byte
+	expectRes(t, "STATX", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepStatx(sqe, zcall.AT_FDCWD, cstr(path), 0, statxSize, unsafe.Pointer(&stx))
+	}), 0)
+	if size := *(*uint64)(unsafe.Pointer(&stx[40])); size != 3 {
+		t.Fatalf("STATX size = %d, want 3", size)
+	}
+
+	sub := filepath.Join(dir, "sub")
+	expectRes(t, "MKDIRAT", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepMkdirat(sqe, zcall.AT_FDCWD, cstr(sub), 0o755) }), 0)
+	moved := filepath.Join(sub, "moved")
+	expectRes(t, "RENAMEAT", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRenameat(sqe, zcall.AT_FDCWD, cstr(path), zcall.AT_FDCWD, cstr(moved), zcall.RENAME_NOREPLACE)
+	}), 0)
+	link := filepath.Join(dir, "symlink")
+	expectRes(t, "SYMLINKAT", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSymlinkat(sqe, cstr(moved), zcall.AT_FDCWD, cstr(link))
+	}), 0)
+	if target, err := os.Readlink(link); err != nil || target != moved {
+		t.Fatalf("Readlink = %q, %v; want %q", target, err, moved)
+	}
+	hard := filepath.Join(dir, "hardlink")
+	expectRes(t, "LINKAT", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepLinkat(sqe, zcall.AT_FDCWD, cstr(moved), zcall.AT_FDCWD, cstr(hard), 0)
+	}), 0)
+	if data, err := os.ReadFile(hard); err != nil || string(data) != "abc" {
+		t.Fatalf("hard link content = %q, %v", data, err)
+	}
+
+	for _, p := range []string{link, hard, moved} {
+		expectRes(t, "UNLINKAT", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepUnlinkat(sqe, zcall.AT_FDCWD, cstr(p), 0) }), 0)
+	}
+	expectRes(t, "UNLINKAT dir", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepUnlinkat(sqe, zcall.AT_FDCWD, cstr(sub), zcall.AT_REMOVEDIR)
+	}), 0)
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("directory not empty after UNLINKAT: %v", entries)
+	}
+}
+
+func TestPrepXattr(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	f, fd := testFile(t)
+	name := cstr("user.zcall")
+
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFsetxattr(sqe, fd, name, []byte("v1"), zcall.XATTR_CREATE) })
+	if cqe.Res == -int32(zcall.EOPNOTSUPP) {
+		t.Skip("user xattrs not supported on the temp filesystem")
+	}
+	expectRes(t, "FSETXATTR", cqe, 0)
+	val := make([]byte, 8)
+	expectRes(t, "FGETXATTR", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFgetxattr(sqe, fd, name, val) }), 2)
+	if string(val[:2]) != "v1" {
+		t.Fatalf("FGETXATTR value = %q", val[:2])
+	}
+
+	path := cstr(f.Name())
+	expectRes(t, "SETXATTR", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSetxattr(sqe, path, name, []byte("v22"), zcall.XATTR_REPLACE)
+	}), 0)
+	expectRes(t, "GETXATTR", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepGetxattr(sqe, path, name, val) }), 3)
+	if string(val[:3]) != "v22" {
+		t.Fatalf("GETXATTR value = %q", val[:3])
+	}
+}
+
+func TestPrepMadvise(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	mem, errno := zcall.Mmap(nil, 4096, zcall.PROT_READ|zcall.PROT_WRITE, zcall.MAP_PRIVATE|zcall.MAP_ANONYMOUS, ^uintptr(0), 0)
+	if errno != 0 {
+		t.Fatalf("Mmap failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Munmap(mem, 4096)
+	*(*byte)(mem) = 1
+	expectRes(t, "MADVISE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepMadvise(sqe, mem, 4096, zcall.MADV_DONTNEED) }), 0)
+	if *(*byte)(mem) != 0 {
+		t.Fatal("MADV_DONTNEED did not discard the private page")
+	}
+}
+
+func TestPrepSpliceTee(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	r1, w1 := testPipe(t)
+	r2, w2 := testPipe(t)
+
+	zcall.Write(uintptr(w1), []byte("data"))
+	expectRes(t, "TEE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepTee(sqe, r1, w2, 4, 0) }), 4)
+	expectRes(t, "SPLICE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepSplice(sqe, r1, -1, w2, -1, 4, 0) }), 4)
+	buf := make([]byte, 16)
+	n, _ := zcall.Read(uintptr(r2), buf)
+	if string(buf[:n]) != "datadata" {
+		t.Fatalf("pipe content = %q, want %q", buf[:n], "datadata")
+	}
+}
+
+func TestPrepPipe(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	var fds [2]int32
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepPipe(sqe, &fds, zcall.O_CLOEXEC) })
+	skipIfUnsupported(t, "PIPE", cqe)
+	expectRes(t, "PIPE", cqe, 0)
+	defer zcall.Close(uintptr(fds[0]))
+	defer zcall.Close(uintptr(fds[1]))
+	zcall.Write(uintptr(fds[1]), []byte("p"))
+	buf := make([]byte, 1)
+	if n, errno := zcall.Read(uintptr(fds[0]), buf); errno != 0 || n != 1 {
+		t.Fatalf("read from new pipe = %d, %v", n, zcall.Errno(errno))
+	}
+}
+
+// epollCreate1 creates an epoll instance for the IORING_OP_EPOLL_* tests.
+func epollCreate1(t *testing.T) uintptr {
+	t.Helper()
+	num := uintptr(20) // arm64, riscv64, loong64
+	if runtime.GOARCH == "amd64" {
+		num = 291
+	}
+	epfd, errno := zcall.Syscall4(num, zcall.EPOLL_CLOEXEC, 0, 0, 0)
+	if errno != 0 {
+		t.Fatalf("epoll_create1 failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { zcall.Close(epfd) })
+	return epfd
+}
+
+func TestPrepEpoll(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	epfd := epollCreate1(t)
+	rfd, wfd := testPipe(t)
+
+	// struct epoll_event begins with the 32-bit event mask on every architecture.
+	var ev [16]byte
+	*(*uint32)(unsafe.Pointer(&ev)) = zcall.EPOLLIN
+	expectRes(t, "EPOLL_CTL", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepEpollCtl(sqe, int32(epfd), rfd, zcall.EPOLL_CTL_ADD, unsafe.Pointer(&ev))
+	}), 0)
+
+	zcall.Write(uintptr(wfd), []byte("e"))
+	var events [4][16]byte
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepEpollWait(sqe, int32(epfd), unsafe.Pointer(&events), 4, 0) })
+	skipIfUnsupported(t, "EPOLL_WAIT", cqe)
+	expectRes(t, "EPOLL_WAIT", cqe, 1)
+}
+
+func TestPrepProvideBuffers(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testSocketpair(t)
+
+	const bgid, size, count = 7, 64, 4
+	pool := make([]byte, size*count)
+	expectRes(t, "PROVIDE_BUFFERS", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepProvideBuffers(sqe, unsafe.Pointer(&pool[0]), size, count, bgid, 0)
+	}), 0)
+
+	zcall.Write(uintptr(a), []byte("select"))
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepRecv(sqe, b, nil, 0)
+		sqe.Flags |= zcall.IOSQE_BUFFER_SELECT
+		sqe.BufIndex = bgid
+	})
+	expectRes(t, "RECV with buffer select", cqe, 6)
+	bid, ok := cqe.BufferID()
+	if !ok || bid >= count {
+		t.Fatalf("RECV cqe flags = %#x, want a selected buffer", cqe.Flags)
+	}
+	if got := string(pool[bid*size : bid*size+6]); got != "select" {
+		t.Fatalf("selected buffer %d = %q", bid, got)
+	}
+
+	expectRes(t, "REMOVE_BUFFERS", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepRemoveBuffers(sqe, count, bgid) }), count-1)
+}
+
+func TestPrepReadMultishot(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, wfd := testPipe(t)
+
+	const bgid, size, count = 3, 32, 4
+	pool := make([]byte, size*count)
+	expectRes(t, "PROVIDE_BUFFERS", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepProvideBuffers(sqe, unsafe.Pointer(&pool[0]), size, count, bgid, 0)
+	}), 0)
+
+	zcall.Write(uintptr(wfd), []byte("multi"))
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepReadMultishot(sqe, rfd, 0, 0, bgid) })
+	skipIfUnsupported(t, "READ_MULTISHOT", cqe)
+	expectRes(t, "READ_MULTISHOT", cqe, 5)
+	if cqe.Flags&zcall.IORING_CQE_F_MORE == 0 {
+		t.Fatalf("READ_MULTISHOT cqe flags = %#x, want more completions", cqe.Flags)
+	}
+}
+
+func TestPrepFilesUpdateAndInstall(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	rfd, wfd := testPipe(t)
+
+	table := [2]int32{-1, -1}
+	if _, errno := zcall.IoUringRegister(r.fd, zcall.IORING_REGISTER_FILES, unsafe.Pointer(&table), 2); errno != 0 {
+		t.Fatalf("IORING_REGISTER_FILES failed: %v", zcall.Errno(errno))
+	}
+	fds := [1]int32{rfd}
+	expectRes(t, "FILES_UPDATE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFilesUpdate(sqe, &fds[0], 1, 1) }), 1)
+
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepFixedFdInstall(sqe, 1, 0) })
+	skipIfUnsupported(t, "FIXED_FD_INSTALL", cqe)
+	if cqe.Res < 0 {
+		t.Fatalf("FIXED_FD_INSTALL failed: %v", zcall.Errno(-cqe.Res))
+	}
+	defer zcall.Close(uintptr(cqe.Res))
+	zcall.Write(uintptr(wfd), []byte("i"))
+	buf := make([]byte, 1)
+	if n, errno := zcall.Read(uintptr(cqe.Res), buf); errno != 0 || n != 1 {
+		t.Fatalf("read from installed fd = %d, %v", n, zcall.Errno(errno))
+	}
+}
+
+func TestPrepMsgRing(t *testing.T) {
+	src := newTestRing(t, 8, 0)
+	dst := newTestRing(t, 8, 0)
+
+	expectRes(t, "MSG_RING", src.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepMsgRing(sqe, int32(dst.fd), 42, 0xbeef, 0)
+	}), 0)
+	dst.enter(1)
+	cqe, ok := dst.pop()
+	if !ok || cqe.UserData != 0xbeef || cqe.Res != 42 {
+		t.Fatalf("target ring completion = %+v, %v", cqe, ok)
+	}
+}
+
+func TestPrepWaitid(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true(1) not available")
+	}
+	r := newTestRing(t, 8, 0)
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+
+	// WNOWAIT leaves the child for cmd.Wait to reap.
+	var info [128]byte
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepWaitid(sqe, zcall.P_PID, uint32(cmd.Process.Pid), unsafe.Pointer(&info), zcall.WEXITED|zcall.WNOWAIT, 0)
+	})
+	skipIfUnsupported(t, "WAITID", cqe)
+	expectRes(t, "WAITID", cqe, 0)
+	// siginfo_t.si_pid is at offset 16.
+	if pid := *(*int32)(unsafe.Pointer(&info[16])); pid != int32(cmd.Process.Pid) {
+		t.Fatalf("WAITID si_pid = %d, want %d", pid, cmd.Process.Pid)
+	}
+}
+
+func TestPrepFutex(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	word := new(uint32)
+	*word = 1
+
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepFutexWake(sqe, word, 1, zcall.FUTEX_BITSET_MATCH_ANY, zcall.FUTEX2_SIZE_U32, 0)
+	})
+	skipIfUnsupported(t, "FUTEX_WAKE", cqe)
+	expectRes(t, "FUTEX_WAKE", cqe, 0)
+	expectRes(t, "FUTEX_WAIT", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepFutexWait(sqe, word, 0, zcall.FUTEX_BITSET_MATCH_ANY, zcall.FUTEX2_SIZE_U32, 0)
+	}), -int32(zcall.EAGAIN))
+
+	waitv := []zcall.FutexWaiter{{Val: 0, Uaddr: uint64(uintptr(unsafe.Pointer(word))), Flags: zcall.FUTEX2_SIZE_U32}}
+	expectRes(t, "FUTEX_WAITV", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepFutexWaitv(sqe, unsafe.Pointer(&waitv[0]), 1, 0)
+	}), -int32(zcall.EAGAIN))
+}
+
+func TestPrepCmdSockQueues(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testTCPPair(t)
+	msg := []byte("queued bytes")
+	zcall.Write(uintptr(a), msg)
+
+	// Loopback delivers the bytes before the write returns.
+	cqe := r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepSiocInq(sqe, b) })
+	skipIfUnsupported(t, "SOCKET_URING_OP_SIOCINQ", cqe)
+	expectRes(t, "SIOCINQ", cqe, int32(len(msg)))
+	// Loopback delivery acknowledges at once, leaving nothing unsent.
+	expectRes(t, "SIOCOUTQ", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepSiocOutq(sqe, a) }), 0)
+}
+
+func TestPrepCmdSockOptions(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, _ := testTCPPair(t)
+
+	val := int32(1)
+	cqe := r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSetsockopt(sqe, a, zcall.IPPROTO_TCP, zcall.TCP_NODELAY, unsafe.Pointer(&val), 4)
+	})
+	skipIfUnsupported(t, "SOCKET_URING_OP_SETSOCKOPT", cqe)
+	expectRes(t, "SETSOCKOPT TCP_NODELAY", cqe, 0)
+	var got int32
+	gotLen := uint32(4)
+	zcall.Getsockopt(uintptr(a), zcall.IPPROTO_TCP, zcall.TCP_NODELAY, unsafe.Pointer(&got), unsafe.Pointer(&gotLen))
+	if got == 0 {
+		t.Fatal("TCP_NODELAY not set")
+	}
+
+	val = 1 << 16
+	expectRes(t, "SETSOCKOPT SO_SNDBUF", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSetsockopt(sqe, a, zcall.SOL_SOCKET, zcall.SO_SNDBUF, unsafe.Pointer(&val), 4)
+	}), 0)
+	got = 0
+	expectRes(t, "GETSOCKOPT SO_SNDBUF", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepGetsockopt(sqe, a, zcall.SOL_SOCKET, zcall.SO_SNDBUF, unsafe.Pointer(&got), 4)
+	}), 4)
+	// The kernel doubles the requested size for bookkeeping overhead.
+	if got != 2*val {
+		t.Fatalf("SO_SNDBUF = %d, want %d", got, 2*val)
+	}
+	// Only SOL_SOCKET options can be read.
+	expectRes(t, "GETSOCKOPT TCP_NODELAY", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepGetsockopt(sqe, a, zcall.IPPROTO_TCP, zcall.TCP_NODELAY, unsafe.Pointer(&got), 4)
+	}), -int32(zcall.EOPNOTSUPP))
+}
+
+func TestPrepTxTimestamp(t *testing.T) {
+	r := newTestRing(t, 8, zcall.IORING_SETUP_CQE32)
+	a, b := testTCPPair(t)
+	opts := int32(zcall.SOF_TIMESTAMPING_TX_SOFTWARE | zcall.SOF_TIMESTAMPING_SOFTWARE |
+		zcall.SOF_TIMESTAMPING_OPT_ID | zcall.SOF_TIMESTAMPING_OPT_TSONLY)
+	if errno := zcall.Setsockopt(uintptr(a), zcall.SOL_SOCKET, zcall.SO_TIMESTAMPING, unsafe.Pointer(&opts), 4); errno != 0 {
+		t.Fatalf("Setsockopt SO_TIMESTAMPING failed: %v", zcall.Errno(errno))
+	}
+
+	r.push(7, func(sqe *zcall.IoUringSqe) { zcall.PrepTxTimestamp(sqe, a) })
+	r.enter(0)
+	if c := r.cq.PeekCQE(); c != nil {
+		skipIfUnsupported(t, "SOCKET_URING_OP_TX_TIMESTAMP", *c)
+		t.Fatalf("completion before any send: %+v", *c)
+	}
+	zcall.Write(uintptr(a), []byte("stamp"))
+	r.enter(1)
+	cqe := r.cq.PeekCQE()
+	if cqe == nil {
+		t.Fatal("no timestamp completion")
+	}
+	c := (*zcall.IoUringCqe32)(unsafe.Pointer(cqe))
+	if c.UserData != 7 || c.Res < 0 || c.Flags&zcall.IORING_CQE_F_MORE == 0 {
+		t.Fatalf("timestamp completion = %+v", *c)
+	}
+	if c.Flags&zcall.IORING_CQE_F_TSTAMP_HW != 0 {
+		t.Fatal("loopback timestamp reported as hardware")
+	}
+	if typ := c.Flags >> zcall.IORING_TIMESTAMP_TYPE_SHIFT; typ != zcall.SCM_TSTAMP_SND {
+		t.Fatalf("timestamp type = %d, want SCM_TSTAMP_SND", typ)
+	}
+	if c.BigCqe[0] == 0 && c.BigCqe[1] == 0 {
+		t.Fatal("zero timestamp")
+	}
+	r.cq.SeenCQE()
+	zcall.Read(uintptr(b), make([]byte, 8))
+
+	// Without 32-byte CQEs there is nowhere to put the timestamp.
+	r16 := newTestRing(t, 8, 0)
+	expectRes(t, "TX_TIMESTAMP", r16.run(func(sqe *zcall.IoUringSqe) { zcall.PrepTxTimestamp(sqe, a) }), -int32(zcall.EINVAL))
+}
+
+func TestPrepUringCmd(t *testing.T) {
+	rfd, _ := testPipe(t)
+
+	// Pipes implement no uring_cmd handler, so the kernel reports EOPNOTSUPP.
+	r := newTestRing(t, 8, 0)
+	expectRes(t, "URING_CMD", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepUringCmd(sqe, 0, rfd) }), -int32(zcall.EOPNOTSUPP))
+}
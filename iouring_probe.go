@@ -0,0 +1,38 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// IoUringOpSet is a bitset of io_uring opcodes.
+type IoUringOpSet [4]uint64
+
+// Supports reports whether op is in the set.
+func (s *IoUringOpSet) Supports(op uint8) bool {
+	return s[op>>6]&(1<<(op&63)) != 0
+}
+
+// add inserts op into the set.
+func (s *IoUringOpSet) add(op uint8) {
+	s[op>>6] |= 1 << (op & 63)
+}
+
+// ProbeRing queries the io_uring instance fd with IORING_REGISTER_PROBE and
+// returns the set of opcodes the running kernel supports.
+func ProbeRing(fd uintptr) (ops IoUringOpSet, errno uintptr) {
+	var p IoUringProbe
+	_, errno = IoUringRegister(fd, IORING_REGISTER_PROBE, unsafe.Pointer(&p), uintptr(len(p.Ops)))
+	if errno != 0 {
+		return ops, errno
+	}
+	for i := range min(int(p.OpsLen), len(p.Ops)) {
+		if p.Ops[i].Flags&IO_URING_OP_SUPPORTED != 0 {
+			ops.add(p.Ops[i].Op)
+		}
+	}
+	return ops, 0
+}
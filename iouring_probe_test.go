@@ -0,0 +1,60 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestIoUringProbeLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringProbeOp{}); got != 8 {
+		t.Errorf("sizeof(IoUringProbeOp) = %d, want 8", got)
+	}
+	if got := unsafe.Offsetof(zcall.IoUringProbe{}.Ops); got != 16 {
+		t.Errorf("offsetof(IoUringProbe.Ops) = %d, want 16", got)
+	}
+}
+
+func TestProbeRing(t *testing.T) {
+	var p zcall.IoUringParams
+	fd := setupRing(t, 4, &p)
+
+	ops, errno := zcall.ProbeRing(fd)
+	if errno != 0 {
+		t.Fatalf("ProbeRing failed: %v", zcall.Errno(errno))
+	}
+	for _, op := range []uint8{zcall.IORING_OP_NOP, zcall.IORING_OP_READV, zcall.IORING_OP_READ, zcall.IORING_OP_SEND} {
+		if !ops.Supports(op) {
+			t.Errorf("opcode %d reported unsupported", op)
+		}
+	}
+	if ops.Supports(255) {
+		t.Error("opcode 255 reported supported")
+	}
+
+	// The set must agree with the raw probe result.
+	var raw zcall.IoUringProbe
+	if _, errno := zcall.IoUringRegister(fd, zcall.IORING_REGISTER_PROBE, unsafe.Pointer(&raw), 256); errno != 0 {
+		t.Fatalf("IORING_REGISTER_PROBE failed: %v", zcall.Errno(errno))
+	}
+	for i := range int(raw.OpsLen) {
+		want := raw.Ops[i].Flags&zcall.IO_URING_OP_SUPPORTED != 0
+		if got := ops.Supports(raw.Ops[i].Op); got != want {
+			t.Errorf("Supports(%d) = %v, want %v", raw.Ops[i].Op, got, want)
+		}
+	}
+}
+
+func TestProbeRingNotRing(t *testing.T) {
+	rfd, _ := testPipe(t)
+	if _, errno := zcall.ProbeRing(uintptr(rfd)); zcall.Errno(errno) != zcall.EOPNOTSUPP {
+		t.Fatalf("ProbeRing(pipe) errno = %v, want EOPNOTSUPP", zcall.Errno(errno))
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Memory ordering.
+//
+// The kernel pairs its ring updates with acquire/release barriers:
+//   - SQ tail is published by the application with a store-release after
+//     the SQEs are written, and SQ head is read with a load-acquire.
+//   - CQ tail is read by the application with a load-acquire before the
+//     CQEs are read, and CQ head is published with a store-release once
+//     the CQEs are consumed.
+//
+// sync/atomic operations are sequentially consistent, which satisfies
+// both sides. Fields written only by the application are cached locally.
+// With IORING_SETUP_SQPOLL, the SQ flags must be read after the tail is
+// published; the sequential consistency of the two operations provides the
+// full barrier the kernel expects there.
+
+// SubmissionQueue is the application side of a mapped submission queue.
+// It hands out SQEs in ring order and publishes them to the kernel on Flush.
+// A SubmissionQueue must be used by a single goroutine at a time.
+type SubmissionQueue struct {
+	head       *uint32
+	tail       *uint32
+	flags      *uint32
+	dropped    *uint32
+	sqes       unsafe.Pointer
+	mask       uint32
+	entries    uint32
+	sqeShift   uint
+	setupFlags uint32
+
+	// sqeHead is the first SQE not yet published; sqeTail is the next
+	// SQE to hand out.
+	sqeHead uint32
+	sqeTail uint32
+}
+
+// NewSubmissionQueue returns a SubmissionQueue over the mapped rings r.
+// Unless the ring was created with IORING_SETUP_NO_SQARRAY, the SQ index
+// array is initialized to the identity mapping so that SQEs are consumed
+// in the order NextSQE returns them.
+func NewSubmissionQueue(r *IoUringRings) *SubmissionQueue {
+	sq := &SubmissionQueue{
+		head:       r.SQ.Head,
+		tail:       r.SQ.Tail,
+		flags:      r.SQ.Flags,
+		dropped:    r.SQ.Dropped,
+		sqes:       r.SQ.SQEs,
+		mask:       *r.SQ.RingMask,
+		entries:    *r.SQ.RingEntries,
+		setupFlags: r.SetupFlags,
+	}
+	if r.SQESize > unsafe.Sizeof(IoUringSqe{}) {
+		sq.sqeShift = 1
+	}
+	if r.SQ.Array != nil {
+		array := unsafe.Slice((*uint32)(r.SQ.Array), sq.entries)
+		for i := range array {
+			array[i] = uint32(i)
+		}
+	}
+	sq.sqeHead = atomic.LoadUint32(sq.tail)
+	sq.sqeTail = sq.sqeHead
+	return sq
+}
+
+// NextSQE returns the next free SQE, or nil if the queue is full.
+// The entry is not visible to the kernel until Flush. In rings created
+// with IORING_SETUP_SQE128, the returned pointer addresses a 128-byte
+// slot and may be converted to *IoUringSqe128.
+func (sq *SubmissionQueue) NextSQE() *IoUringSqe {
+	head := atomic.LoadUint32(sq.head)
+	if sq.sqeTail-head >= sq.entries {
+		return nil
+	}
+	off := uintptr(sq.sqeTail&sq.mask) << (6 + sq.sqeShift)
+	sq.sqeTail++
+	return (*IoUringSqe)(unsafe.Add(sq.sqes, off))
+}
+
+// NextSQE128 returns two adjacent free SQE slots for a 128-byte request,
+// such as IORING_OP_NOP128 or IORING_OP_URING_CMD128, in a ring created
+// with IORING_SETUP_SQE_MIXED. A 128-byte entry cannot wrap around the end
+// of the ring, so when the next free slot is the last one it is filled with
+// a NOP that posts no completion. NextSQE128 returns nil if the queue is
+// full or the ring was not created with IORING_SETUP_SQE_MIXED.
+func (sq *SubmissionQueue) NextSQE128() *IoUringSqe128 {
+	if sq.setupFlags&IORING_SETUP_SQE_MIXED == 0 {
+		return nil
+	}
+	head := atomic.LoadUint32(sq.head)
+	tail := sq.sqeTail
+	pad := tail&sq.mask == sq.mask
+	need := uint32(2)
+	if pad {
+		need++
+	}
+	if tail-head+need > sq.entries {
+		return nil
+	}
+	if pad {
+		sqe := (*IoUringSqe)(unsafe.Add(sq.sqes, uintptr(tail&sq.mask)<<6))
+		PrepNop(sqe)
+		sqe.Flags = IOSQE_CQE_SKIP_SUCCESS
+		tail++
+	}
+	sq.sqeTail = tail + 2
+	return (*IoUringSqe128)(unsafe.Add(sq.sqes, uintptr(tail&sq.mask)<<6))
+}
+
+// Flush publishes the SQEs obtained from NextSQE to the kernel by
+// advancing the shared SQ tail. It returns the number of entries the
+// kernel has not yet consumed, which is the toSubmit count for IoUringEnter.
+func (sq *SubmissionQueue) Flush() uint32 {
+	tail := sq.sqeTail
+	if sq.sqeHead != tail {
+		sq.sqeHead = tail
+		atomic.StoreUint32(sq.tail, tail)
+	}
+	return tail - atomic.LoadUint32(sq.head)
+}
+
+// Submit flushes pending SQEs and submits them to the io_uring instance fd.
+// It is SubmitAndWait with no completions to wait for.
+func (sq *SubmissionQueue) Submit(fd uintptr) (n uintptr, errno uintptr) {
+	return sq.SubmitAndWait(fd, 0, 0)
+}
+
+// SubmitAndWait flushes pending SQEs and calls IoUringEnter only when the
+// kernel needs it: to submit on a ring without IORING_SETUP_SQPOLL, to wake
+// an idle SQ thread, to wait for waitNr completions, or to flush overflowed
+// completions and pending task work. An SQPOLL ring whose thread is awake
+// is thus driven without system calls. flags are extra IORING_ENTER_* flags,
+// such as IORING_ENTER_REGISTERED_RING.
+//
+// It returns the number of SQEs submitted, or, when no system call was
+// needed, the number handed to the SQ thread.
+func (sq *SubmissionQueue) SubmitAndWait(fd, waitNr, flags uintptr) (n uintptr, errno uintptr) {
+	submitted := sq.Flush()
+	enter := false
+	if submitted != 0 {
+		if sq.setupFlags&IORING_SETUP_SQPOLL == 0 {
+			enter = true
+		} else if atomic.LoadUint32(sq.flags)&IORING_SQ_NEED_WAKEUP != 0 {
+			flags |= IORING_ENTER_SQ_WAKEUP
+			enter = true
+		}
+	}
+	if waitNr != 0 || sq.setupFlags&IORING_SETUP_IOPOLL != 0 || sq.CQNeedsFlush() {
+		flags |= IORING_ENTER_GETEVENTS
+		enter = true
+	}
+	if !enter {
+		return uintptr(submitted), 0
+	}
+	return IoUringEnter(fd, uintptr(submitted), waitNr, flags, nil, 0)
+}
+
+// NeedsWakeup reports whether the SQ thread of an IORING_SETUP_SQPOLL ring
+// has gone idle and must be woken with IORING_ENTER_SQ_WAKEUP.
+func (sq *SubmissionQueue) NeedsWakeup() bool {
+	return atomic.LoadUint32(sq.flags)&IORING_SQ_NEED_WAKEUP != 0
+}
+
+// CQNeedsFlush reports whether completions are held back by the kernel,
+// either because the completion queue overflowed (IORING_SQ_CQ_OVERFLOW)
+// or because task work is pending (IORING_SQ_TASKRUN). An io_uring_enter
+// with IORING_ENTER_GETEVENTS moves them into the completion queue.
+func (sq *SubmissionQueue) CQNeedsFlush() bool {
+	return atomic.LoadUint32(sq.flags)&(IORING_SQ_CQ_OVERFLOW|IORING_SQ_TASKRUN) != 0
+}
+
+// SQSpaceLeft returns the number of SQEs NextSQE can still hand out.
+func (sq *SubmissionQueue) SQSpaceLeft() uint32 {
+	return sq.entries - (sq.sqeTail - atomic.LoadUint32(sq.head))
+}
+
+// SQReady returns the number of SQEs handed out but not yet flushed.
+func (sq *SubmissionQueue) SQReady() uint32 {
+	return sq.sqeTail - sq.sqeHead
+}
+
+// Entries returns the number of entries in the submission queue.
+func (sq *SubmissionQueue) Entries() uint32 {
+	return sq.entries
+}
+
+// Dropped returns the number of invalid SQEs the kernel has dropped.
+func (sq *SubmissionQueue) Dropped() uint32 {
+	return atomic.LoadUint32(sq.dropped)
+}
+
+// CompletionQueue is the application side of a mapped completion queue.
+// A CompletionQueue must be used by a single goroutine at a time.
+//
+// In rings created with IORING_SETUP_CQE_MIXED, a 32-byte completion
+// flagged IORING_CQE_F_32 takes two slots, and the kernel fills a slot it
+// cannot use with an entry flagged IORING_CQE_F_SKIP. PeekCQE steps over
+// such filler entries and SeenCQE releases both slots of a 32-byte entry.
+type CompletionQueue struct {
+	head       *uint32
+	tail       *uint32
+	overflow   *uint32
+	cqes       unsafe.Pointer
+	mask       uint32
+	entries    uint32
+	cqeShift   uint
+	setupFlags uint32
+}
+
+// NewCompletionQueue returns a CompletionQueue over the mapped rings r.
+func NewCompletionQueue(r *IoUringRings) *CompletionQueue {
+	cq := &CompletionQueue{
+		head:       r.CQ.Head,
+		tail:       r.CQ.Tail,
+		overflow:   r.CQ.Overflow,
+		cqes:       r.CQ.CQEs,
+		mask:       *r.CQ.RingMask,
+		entries:    *r.CQ.RingEntries,
+		setupFlags: r.SetupFlags,
+	}
+	if r.CQESize > unsafe.Sizeof(IoUringCqe{}) {
+		cq.cqeShift = 1
+	}
+	return cq
+}
+
+// PeekCQE returns the oldest unconsumed CQE, or nil if the queue is empty.
+// The entry remains valid until SeenCQE or Advance releases it. In rings
+// created with IORING_SETUP_CQE32, or with IORING_SETUP_CQE_MIXED when the
+// entry is flagged IORING_CQE_F_32, the returned pointer may be converted
+// to *IoUringCqe32.
+func (cq *CompletionQueue) PeekCQE() *IoUringCqe {
+	for {
+		head := *cq.head
+		if head == atomic.LoadUint32(cq.tail) {
+			return nil
+		}
+		cqe := cq.at(head)
+		if cq.setupFlags&IORING_SETUP_CQE_MIXED != 0 && cqe.Flags&IORING_CQE_F_SKIP != 0 {
+			atomic.StoreUint32(cq.head, head+1)
+			continue
+		}
+		return cqe
+	}
+}
+
+// SeenCQE releases the CQE returned by PeekCQE back to the kernel.
+func (cq *CompletionQueue) SeenCQE() {
+	head := *cq.head
+	if cq.setupFlags&IORING_SETUP_CQE_MIXED != 0 && cq.at(head).Flags&IORING_CQE_F_32 != 0 {
+		atomic.StoreUint32(cq.head, head+2)
+		return
+	}
+	atomic.StoreUint32(cq.head, head+1)
+}
+
+// Advance releases n CQE slots back to the kernel. In rings created with
+// IORING_SETUP_CQE_MIXED, a 32-byte entry counts as two slots and a filler
+// entry as one; SeenCQE accounts for both.
+func (cq *CompletionQueue) Advance(n uint32) {
+	atomic.StoreUint32(cq.head, *cq.head+n)
+}
+
+// at returns the CQE in the slot of ring index i.
+func (cq *CompletionQueue) at(i uint32) *IoUringCqe {
+	off := uintptr(i&cq.mask) << (4 + cq.cqeShift)
+	return (*IoUringCqe)(unsafe.Add(cq.cqes, off))
+}
+
+// CQReady returns the number of CQE slots available to consume. In rings
+// created with IORING_SETUP_CQE_MIXED, this counts 32-byte entries twice.
+func (cq *CompletionQueue) CQReady() uint32 {
+	return atomic.LoadUint32(cq.tail) - *cq.head
+}
+
+// Entries returns the number of entries in the completion queue.
+func (cq *CompletionQueue) Entries() uint32 {
+	return cq.entries
+}
+
+// Overflow returns the number of completions the kernel could not post
+// because the completion queue was full.
+func (cq *CompletionQueue) Overflow() uint32 {
+	return atomic.LoadUint32(cq.overflow)
+}
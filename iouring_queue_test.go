@@ -0,0 +1,407 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestSubmissionQueueFull(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	sq, cq := r.sq, r.cq
+
+	if sq.Entries() != 4 || sq.SQSpaceLeft() != 4 {
+		t.Fatalf("fresh queue: entries = %d, space = %d", sq.Entries(), sq.SQSpaceLeft())
+	}
+	for i := range 4 {
+		sqe := sq.NextSQE()
+		if sqe == nil {
+			t.Fatalf("NextSQE returned nil after %d entries", i)
+		}
+		zcall.PrepNop(sqe)
+		sqe.UserData = uint64(i)
+	}
+	if sq.NextSQE() != nil {
+		t.Fatal("NextSQE returned an entry from a full queue")
+	}
+	if sq.SQSpaceLeft() != 0 || sq.SQReady() != 4 {
+		t.Fatalf("full queue: space = %d, ready = %d", sq.SQSpaceLeft(), sq.SQReady())
+	}
+	if cq.PeekCQE() != nil || cq.CQReady() != 0 {
+		t.Fatal("completion queue not empty before submission")
+	}
+
+	if n := sq.Flush(); n != 4 {
+		t.Fatalf("Flush = %d, want 4", n)
+	}
+	if sq.SQReady() != 0 {
+		t.Fatalf("SQReady after Flush = %d, want 0", sq.SQReady())
+	}
+	if _, errno := zcall.IoUringEnter(r.fd, 4, 4, zcall.IORING_ENTER_GETEVENTS, nil, 0); errno != 0 {
+		t.Fatalf("IoUringEnter failed: %v", zcall.Errno(errno))
+	}
+	if sq.SQSpaceLeft() != 4 {
+		t.Fatalf("SQSpaceLeft after submission = %d, want 4", sq.SQSpaceLeft())
+	}
+	if cq.CQReady() != 4 {
+		t.Fatalf("CQReady = %d, want 4", cq.CQReady())
+	}
+	for i := range 4 {
+		cqe := cq.PeekCQE()
+		if cqe == nil || cqe.UserData != uint64(i) {
+			t.Fatalf("completion %d = %+v", i, cqe)
+		}
+		cq.SeenCQE()
+	}
+	if cq.PeekCQE() != nil {
+		t.Fatal("completion queue not empty after consuming all entries")
+	}
+}
+
+func TestQueueWraparound(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags uint32
+	}{
+		{"default", 0},
+		{"no_sqarray", zcall.IORING_SETUP_NO_SQARRAY},
+		{"sqe128_cqe32", zcall.IORING_SETUP_SQE128 | zcall.IORING_SETUP_CQE32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRing(t, 4, tt.flags)
+			var next uint64
+			for round := range 5 {
+				for range 3 {
+					r.push(next, zcall.PrepNop)
+					next++
+				}
+				r.enter(3)
+				if r.cq.CQReady() != 3 {
+					t.Fatalf("round %d: CQReady = %d, want 3", round, r.cq.CQReady())
+				}
+				for i := range uint64(3) {
+					cqe, ok := r.pop()
+					if !ok || cqe.UserData != next-3+i || cqe.Res != 0 {
+						t.Fatalf("round %d: completion %d = %+v", round, i, cqe)
+					}
+				}
+			}
+			if r.sq.Dropped() != 0 || r.cq.Overflow() != 0 {
+				t.Fatalf("dropped = %d, overflow = %d", r.sq.Dropped(), r.cq.Overflow())
+			}
+		})
+	}
+}
+
+func TestCompletionQueueAdvance(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	for i := range uint64(3) {
+		r.push(i, zcall.PrepNop)
+	}
+	r.enter(3)
+	if cqe := r.cq.PeekCQE(); cqe == nil || cqe.UserData != 0 {
+		t.Fatalf("first completion = %+v", cqe)
+	}
+	r.cq.Advance(2)
+	if r.cq.CQReady() != 1 {
+		t.Fatalf("CQReady after Advance(2) = %d, want 1", r.cq.CQReady())
+	}
+	if cqe := r.cq.PeekCQE(); cqe == nil || cqe.UserData != 2 {
+		t.Fatalf("completion after Advance(2) = %+v", cqe)
+	}
+}
+
+func TestSubmitAndWait(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	for range 3 {
+		zcall.PrepNop(r.sq.NextSQE())
+	}
+	n, errno := r.sq.SubmitAndWait(r.fd, 3, 0)
+	if errno != 0 || n != 3 {
+		t.Fatalf("SubmitAndWait = %d, %v; want 3, 0", n, zcall.Errno(errno))
+	}
+	if r.cq.CQReady() != 3 {
+		t.Fatalf("CQReady = %d, want 3", r.cq.CQReady())
+	}
+	r.cq.Advance(3)
+
+	// Nothing to submit and nothing to wait for needs no system call.
+	if n, errno := r.sq.Submit(^uintptr(0)); errno != 0 || n != 0 {
+		t.Fatalf("empty Submit = %d, %v; want 0, 0", n, zcall.Errno(errno))
+	}
+
+	// Without SQPOLL, submission always enters the kernel.
+	zcall.PrepNop(r.sq.NextSQE())
+	if _, errno := r.sq.Submit(^uintptr(0)); zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("Submit on a bad fd errno = %v, want EBADF", zcall.Errno(errno))
+	}
+	// The entry is still pending and goes out with the next Submit.
+	if n, errno := r.sq.Submit(r.fd); errno != 0 || n != 1 {
+		t.Fatalf("Submit = %d, %v; want 1, 0", n, zcall.Errno(errno))
+	}
+}
+
+func TestSubmitSQPoll(t *testing.T) {
+	p := zcall.IoUringParams{Flags: zcall.IORING_SETUP_SQPOLL, SqThreadIdle: 20}
+	r := newTestRingParams(t, 8, &p)
+
+	// Give the SQ thread time to go idle.
+	deadline := time.Now().Add(2 * time.Second)
+	for !r.sq.NeedsWakeup() {
+		if time.Now().After(deadline) {
+			t.Fatal("SQ thread never set IORING_SQ_NEED_WAKEUP")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if _, errno := r.sq.Submit(r.fd); errno != 0 {
+		t.Fatalf("Submit with wakeup failed: %v", zcall.Errno(errno))
+	}
+	waitCQE(t, r, 1)
+
+	// While the thread is awake, submission is a store to the SQ tail:
+	// an invalid fd proves that no system call is made.
+	for i := range 16 {
+		if r.sq.NeedsWakeup() {
+			t.Skip("SQ thread went idle during the test")
+		}
+		r.push(uint64(i+2), func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+		if _, errno := r.sq.Submit(^uintptr(0)); errno != 0 {
+			if r.sq.NeedsWakeup() {
+				t.Skip("SQ thread went idle during the test")
+			}
+			t.Fatalf("Submit made a system call: %v", zcall.Errno(errno))
+		}
+		waitCQE(t, r, uint64(i+2))
+	}
+}
+
+// waitCQE polls the completion queue until the completion for userData
+// arrives, for rings whose submissions complete without io_uring_enter.
+func waitCQE(t *testing.T, r *testRing, userData uint64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if cqe, ok := r.pop(); ok {
+			if cqe.UserData != userData {
+				t.Fatalf("completion UserData = %d, want %d", cqe.UserData, userData)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no completion for %d", userData)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubmitFlushesCQOverflow(t *testing.T) {
+	var p zcall.IoUringParams
+	r := newTestRingParams(t, 4, &p)
+	if p.Features&zcall.IORING_FEAT_NODROP == 0 {
+		t.Skip("IORING_FEAT_NODROP not supported on this kernel")
+	}
+
+	// Three rounds of four completions overflow the eight-entry CQ.
+	const total = 12
+	for i := range uint64(total) {
+		r.push(i, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+		if i%4 == 3 {
+			if _, errno := r.sq.Submit(r.fd); errno != 0 {
+				t.Fatalf("Submit failed: %v", zcall.Errno(errno))
+			}
+		}
+	}
+	if !r.sq.CQNeedsFlush() {
+		t.Fatal("IORING_SQ_CQ_OVERFLOW not set after overflowing the completion queue")
+	}
+
+	var seen []uint64
+	for len(seen) < total {
+		for {
+			cqe, ok := r.pop()
+			if !ok {
+				break
+			}
+			seen = append(seen, cqe.UserData)
+		}
+		if len(seen) == total {
+			break
+		}
+		if !r.sq.CQNeedsFlush() {
+			t.Fatalf("lost completions: got %v", seen)
+		}
+		// Nothing to submit: Submit enters only to flush the overflow.
+		if _, errno := r.sq.Submit(r.fd); errno != 0 {
+			t.Fatalf("Submit failed: %v", zcall.Errno(errno))
+		}
+	}
+	for i, ud := range seen {
+		if ud != uint64(i) {
+			t.Fatalf("completions out of order: %v", seen)
+		}
+	}
+	if r.sq.CQNeedsFlush() {
+		t.Fatal("IORING_SQ_CQ_OVERFLOW still set after draining")
+	}
+}
+
+// fakeRing lays out the queues of a ring in ordinary memory, so that the
+// queue logic can be tested with setup flags the running kernel may not
+// support. The completion queue has twice as many entries as the
+// submission queue.
+type fakeRing struct {
+	rings    zcall.IoUringRings
+	sqes     []zcall.IoUringSqe
+	sqHead   uint32
+	sqTail   uint32
+	sqMask   uint32
+	sqN      uint32
+	sqFlags  uint32
+	dropped  uint32
+	cqes     []zcall.IoUringCqe
+	cqHead   uint32
+	cqTail   uint32
+	cqMask   uint32
+	cqN      uint32
+	cqFlags  uint32
+	overflow uint32
+}
+
+func newFakeRing(entries, flags uint32) *fakeRing {
+	f := &fakeRing{
+		sqes:   make([]zcall.IoUringSqe, entries),
+		sqMask: entries - 1,
+		sqN:    entries,
+		cqes:   make([]zcall.IoUringCqe, 2*entries),
+		cqMask: 2*entries - 1,
+		cqN:    2 * entries,
+	}
+	f.rings = zcall.IoUringRings{
+		SQ: zcall.IoUringSQ{
+			Head:        &f.sqHead,
+			Tail:        &f.sqTail,
+			RingMask:    &f.sqMask,
+			RingEntries: &f.sqN,
+			Flags:       &f.sqFlags,
+			Dropped:     &f.dropped,
+			SQEs:        unsafe.Pointer(&f.sqes[0]),
+		},
+		CQ: zcall.IoUringCQ{
+			Head:        &f.cqHead,
+			Tail:        &f.cqTail,
+			RingMask:    &f.cqMask,
+			RingEntries: &f.cqN,
+			Overflow:    &f.overflow,
+			Flags:       &f.cqFlags,
+			CQEs:        unsafe.Pointer(&f.cqes[0]),
+		},
+		SQESize:    unsafe.Sizeof(zcall.IoUringSqe{}),
+		CQESize:    unsafe.Sizeof(zcall.IoUringCqe{}),
+		SetupFlags: flags,
+	}
+	return f
+}
+
+func TestNextSQE128(t *testing.T) {
+	plain := zcall.NewSubmissionQueue(&newFakeRing(4, 0).rings)
+	if plain.NextSQE128() != nil {
+		t.Fatal("NextSQE128 returned an entry in a ring without IORING_SETUP_SQE_MIXED")
+	}
+
+	f := newFakeRing(4, zcall.IORING_SETUP_SQE_MIXED)
+	sq := zcall.NewSubmissionQueue(&f.rings)
+
+	// At slot mask-1 the entry fits without padding.
+	sq.NextSQE()
+	sq.NextSQE()
+	if sqe := sq.NextSQE128(); sqe == nil || unsafe.Pointer(sqe) != unsafe.Pointer(&f.sqes[2]) {
+		t.Fatalf("NextSQE128 at slot 2 = %p, want %p", sqe, &f.sqes[2])
+	}
+	if sq.SQReady() != 4 || sq.NextSQE128() != nil {
+		t.Fatalf("full queue: ready = %d", sq.SQReady())
+	}
+
+	// The kernel consumes the first slot; one more is not enough.
+	sq.Flush()
+	f.sqHead = 1
+	if sq.NextSQE128() != nil {
+		t.Fatal("NextSQE128 returned an entry with one free slot")
+	}
+
+	// At the last slot, the slot is padded and the entry wraps around.
+	sq.Flush()
+	f.sqHead = 4
+	sq.NextSQE()
+	sq.NextSQE()
+	sq.NextSQE()
+	f.sqes[3] = zcall.IoUringSqe{Opcode: zcall.IORING_OP_READ, UserData: 99}
+	sq.Flush()
+	f.sqHead = 7
+	sqe := sq.NextSQE128()
+	if sqe == nil || unsafe.Pointer(sqe) != unsafe.Pointer(&f.sqes[0]) {
+		t.Fatalf("NextSQE128 at the last slot = %p, want %p", sqe, &f.sqes[0])
+	}
+	pad := f.sqes[3]
+	if pad.Opcode != zcall.IORING_OP_NOP || pad.Flags != zcall.IOSQE_CQE_SKIP_SUCCESS || pad.UserData != 0 {
+		t.Fatalf("padding entry = %+v, want a NOP with IOSQE_CQE_SKIP_SUCCESS", pad)
+	}
+	if sq.SQReady() != 3 || sq.SQSpaceLeft() != 1 {
+		t.Fatalf("after padding: ready = %d, space = %d; want 3, 1", sq.SQReady(), sq.SQSpaceLeft())
+	}
+	if n := sq.Flush(); n != 3 || f.sqTail != 10 {
+		t.Fatalf("Flush = %d, tail = %d; want 3, 10", n, f.sqTail)
+	}
+}
+
+func TestCompletionQueueMixed(t *testing.T) {
+	f := newFakeRing(4, zcall.IORING_SETUP_CQE_MIXED)
+	cq := zcall.NewCompletionQueue(&f.rings)
+
+	// A 16-byte entry, a 32-byte entry, a filler in the last slot, and a
+	// 32-byte entry wrapped to the start of the ring.
+	f.cqHead, f.cqTail = 4, 12
+	f.cqes[4] = zcall.IoUringCqe{UserData: 1}
+	f.cqes[5] = zcall.IoUringCqe{UserData: 2, Flags: zcall.IORING_CQE_F_32}
+	f.cqes[6] = zcall.IoUringCqe{UserData: 0xbad, Res: -1}
+	f.cqes[7] = zcall.IoUringCqe{Flags: zcall.IORING_CQE_F_SKIP}
+	f.cqes[0] = zcall.IoUringCqe{UserData: 3, Flags: zcall.IORING_CQE_F_32}
+	f.cqes[1] = zcall.IoUringCqe{UserData: 0xbad, Res: -1}
+	f.cqes[2] = zcall.IoUringCqe{UserData: 4}
+	f.cqes[3] = zcall.IoUringCqe{UserData: 5}
+	if cq.CQReady() != 8 {
+		t.Fatalf("CQReady = %d, want 8 slots", cq.CQReady())
+	}
+	for _, want := range []uint64{1, 2, 3, 4} {
+		cqe := cq.PeekCQE()
+		if cqe == nil || cqe.UserData != want {
+			t.Fatalf("PeekCQE = %+v, want user data %d", cqe, want)
+		}
+		cq.SeenCQE()
+	}
+	if f.cqHead != 11 || cq.CQReady() != 1 {
+		t.Fatalf("head = %d, ready = %d; want 11, 1", f.cqHead, cq.CQReady())
+	}
+
+	// Without IORING_SETUP_CQE_MIXED every entry takes one slot.
+	f = newFakeRing(4, 0)
+	cq = zcall.NewCompletionQueue(&f.rings)
+	f.cqes[0] = zcall.IoUringCqe{UserData: 1, Flags: zcall.IORING_CQE_F_32}
+	f.cqes[1] = zcall.IoUringCqe{UserData: 2, Flags: zcall.IORING_CQE_F_SKIP}
+	f.cqTail = 2
+	for _, want := range []uint64{1, 2} {
+		if cqe := cq.PeekCQE(); cqe == nil || cqe.UserData != want {
+			t.Fatalf("PeekCQE = %+v, want user data %d", cqe, want)
+		}
+		cq.SeenCQE()
+	}
+}
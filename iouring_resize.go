@@ -0,0 +1,149 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// cacheLineSize is the kernel SMP_CACHE_BYTES of the supported targets.
+const cacheLineSize = 64
+
+// ringState is one generation of the mappings and queue accessors of a
+// ResizableRing.
+type ringState struct {
+	rings IoUringRings
+	sq    *SubmissionQueue
+	cq    *CompletionQueue
+}
+
+// ResizableRing holds the mappings and queue accessors of an io_uring
+// instance that can be resized at runtime with IORING_REGISTER_RESIZE_RINGS.
+// Resize replaces the mappings and publishes the new accessors with a
+// single atomic store, so SQ, CQ, and Rings always return a matching set.
+//
+// The kernel only resizes rings created with IORING_SETUP_DEFER_TASKRUN,
+// which implies IORING_SETUP_SINGLE_ISSUER: Resize must be called from the
+// thread that submits to the ring, and accessors obtained before a Resize
+// must not be used after it. A Resize that fails after the kernel replaced
+// the rings leaves the ResizableRing dead: SQ, CQ, and Rings return nil,
+// and the ring fd must be closed.
+type ResizableRing struct {
+	fd  uintptr
+	cur atomic.Pointer[ringState]
+}
+
+// NewResizableRing maps the rings of the io_uring instance fd, created with
+// the parameters p.
+func NewResizableRing(fd uintptr, p *IoUringParams) (r *ResizableRing, errno uintptr) {
+	s := new(ringState)
+	s.rings, errno = IoUringMapRings(fd, p)
+	if errno != 0 {
+		return nil, errno
+	}
+	s.sq = NewSubmissionQueue(&s.rings)
+	s.cq = NewCompletionQueue(&s.rings)
+	r = &ResizableRing{fd: fd}
+	r.cur.Store(s)
+	return r, 0
+}
+
+// SQ returns the current submission queue accessor, or nil if the ring is
+// closed or dead.
+func (r *ResizableRing) SQ() *SubmissionQueue {
+	if s := r.cur.Load(); s != nil {
+		return s.sq
+	}
+	return nil
+}
+
+// CQ returns the current completion queue accessor, or nil if the ring is
+// closed or dead.
+func (r *ResizableRing) CQ() *CompletionQueue {
+	if s := r.cur.Load(); s != nil {
+		return s.cq
+	}
+	return nil
+}
+
+// Rings returns the current ring mappings, or nil if the ring is closed or
+// dead.
+func (r *ResizableRing) Rings() *IoUringRings {
+	if s := r.cur.Load(); s != nil {
+		return &s.rings
+	}
+	return nil
+}
+
+// Resize resizes the submission queue to sqEntries and the completion queue
+// to cqEntries entries; a zero cqEntries selects the kernel default of
+// twice sqEntries. Both are rounded up to a power of two. SQEs handed out
+// by NextSQE are flushed first, and the kernel carries pending SQEs and
+// CQEs over to the new rings.
+//
+// errno is EINVAL when the ring was not created with
+// IORING_SETUP_DEFER_TASKRUN, was created with IORING_SETUP_NO_MMAP, or the
+// kernel lacks IORING_REGISTER_RESIZE_RINGS; EOVERFLOW when the pending
+// entries do not fit the new sizes; EBADF when the ring is closed or dead.
+// When the kernel rejects the resize, the current rings remain in use. When
+// the kernel resized the rings but mapping the new ones failed, the old
+// mappings no longer describe the ring: they are released, the ring is
+// dead, and the caller must close the ring fd.
+func (r *ResizableRing) Resize(sqEntries, cqEntries uint32) (errno uintptr) {
+	old := r.cur.Load()
+	if old == nil {
+		return uintptr(EBADF)
+	}
+	if old.rings.SetupFlags&IORING_SETUP_DEFER_TASKRUN == 0 || old.rings.SetupFlags&IORING_SETUP_NO_MMAP != 0 {
+		return uintptr(EINVAL)
+	}
+	p := IoUringParams{SqEntries: sqEntries}
+	if cqEntries != 0 {
+		p.Flags = IORING_SETUP_CQSIZE
+		p.CqEntries = cqEntries
+	}
+	old.sq.Flush()
+	if _, errno = IoUringRegister(r.fd, IORING_REGISTER_RESIZE_RINGS, unsafe.Pointer(&p), 1); errno != 0 {
+		return errno
+	}
+
+	// The kernel writes back the new sizes and offsets, except for the SQ
+	// index array, which follows the CQEs at the next cache line. Setup
+	// flags carry over from the old rings, and so does the shared-mapping
+	// feature.
+	p.Flags |= old.rings.SetupFlags &^ IORING_SETUP_CQSIZE
+	if p.Flags&IORING_SETUP_NO_SQARRAY == 0 && p.SqOff.Array == 0 {
+		end := uintptr(p.CqOff.Cqes) + uintptr(p.CqEntries)*old.rings.CQESize
+		p.SqOff.Array = uint32((end + cacheLineSize - 1) &^ (cacheLineSize - 1))
+	}
+	if old.rings.cqRing == old.rings.sqRing {
+		p.Features |= IORING_FEAT_SINGLE_MMAP
+	}
+	s := new(ringState)
+	s.rings, errno = IoUringMapRings(r.fd, &p)
+	if errno != 0 {
+		r.cur.Store(nil)
+		old.rings.Unmap()
+		return errno
+	}
+	s.sq = NewSubmissionQueue(&s.rings)
+	s.cq = NewCompletionQueue(&s.rings)
+	r.cur.Store(s)
+	// The old mappings still reference the freed rings' pages, which the
+	// kernel releases once they are unmapped.
+	return old.rings.Unmap()
+}
+
+// Close unmaps the rings. The ring fd is left open.
+func (r *ResizableRing) Close() (errno uintptr) {
+	s := r.cur.Swap(nil)
+	if s == nil {
+		return 0
+	}
+	return s.rings.Unmap()
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"runtime"
+	"testing"
+
+	"code.hybscloud.com/zcall"
+)
+
+// newTestResizableRing creates a DEFER_TASKRUN ring owned by the calling
+// thread, which the caller must have locked.
+func newTestResizableRing(t *testing.T, entries uintptr) (uintptr, *zcall.ResizableRing) {
+	t.Helper()
+	p := zcall.IoUringParams{Flags: zcall.IORING_SETUP_SINGLE_ISSUER | zcall.IORING_SETUP_DEFER_TASKRUN}
+	fd := setupRing(t, entries, &p)
+	r, errno := zcall.NewResizableRing(fd, &p)
+	if errno != 0 {
+		t.Fatalf("NewResizableRing failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { r.Close() })
+	return fd, r
+}
+
+func submitNops(t *testing.T, fd uintptr, r *zcall.ResizableRing, first, n uint64) {
+	t.Helper()
+	sq := r.SQ()
+	for i := first; i < first+n; i++ {
+		sqe := sq.NextSQE()
+		if sqe == nil {
+			t.Fatalf("submission queue full at %d", i)
+		}
+		zcall.PrepNop(sqe)
+		sqe.UserData = i
+	}
+	if _, errno := sq.Submit(fd); errno != 0 {
+		t.Fatalf("Submit failed: %v", zcall.Errno(errno))
+	}
+}
+
+func TestResizableRingResize(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	fd, r := newTestResizableRing(t, 4)
+
+	// Completions pending across the resize must survive it.
+	submitNops(t, fd, r, 0, 4)
+	errno := r.Resize(64, 256)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_RESIZE_RINGS not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("Resize failed: %v", zcall.Errno(errno))
+	}
+	if got := r.SQ().Entries(); got != 64 {
+		t.Fatalf("SQ entries = %d, want 64", got)
+	}
+	if got := r.CQ().Entries(); got != 256 {
+		t.Fatalf("CQ entries = %d, want 256", got)
+	}
+	if flags := r.Rings().SetupFlags; flags&zcall.IORING_SETUP_DEFER_TASKRUN == 0 {
+		t.Fatalf("setup flags = %#x, lost IORING_SETUP_DEFER_TASKRUN", flags)
+	}
+
+	// The new rings take more than the old ones could hold.
+	submitNops(t, fd, r, 4, 60)
+	cq := r.CQ()
+	for i := range uint64(64) {
+		cqe := cq.PeekCQE()
+		if cqe == nil || cqe.UserData != i || cqe.Res != 0 {
+			t.Fatalf("completion %d = %+v", i, cqe)
+		}
+		cq.SeenCQE()
+	}
+
+	// Shrinking back works, and a second resize keeps the inherited flags.
+	if errno := r.Resize(8, 0); errno != 0 {
+		t.Fatalf("second Resize failed: %v", zcall.Errno(errno))
+	}
+	if got := r.CQ().Entries(); got != 16 {
+		t.Fatalf("CQ entries = %d, want 16", got)
+	}
+	submitNops(t, fd, r, 0, 8)
+	if got := r.CQ().CQReady(); got != 8 {
+		t.Fatalf("CQReady = %d, want 8", got)
+	}
+}
+
+func TestResizableRingOverflow(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	fd, r := newTestResizableRing(t, 16)
+	submitNops(t, fd, r, 0, 16)
+	errno := r.Resize(4, 8)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_RESIZE_RINGS not supported on this kernel")
+	}
+	if zcall.Errno(errno) != zcall.EOVERFLOW {
+		t.Fatalf("Resize errno = %v, want EOVERFLOW", zcall.Errno(errno))
+	}
+	// The old rings stay in use.
+	if got := r.CQ().CQReady(); got != 16 {
+		t.Fatalf("CQReady = %d, want 16", got)
+	}
+}
+
+func TestResizableRingRequiresDeferTaskrun(t *testing.T) {
+	var p zcall.IoUringParams
+	fd := setupRing(t, 4, &p)
+	r, errno := zcall.NewResizableRing(fd, &p)
+	if errno != 0 {
+		t.Fatalf("NewResizableRing failed: %v", zcall.Errno(errno))
+	}
+	defer r.Close()
+	if errno := r.Resize(8, 0); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("Resize errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+func TestResizableRingClosed(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	_, r := newTestResizableRing(t, 4)
+	if errno := r.Close(); errno != 0 {
+		t.Fatalf("Close failed: %v", zcall.Errno(errno))
+	}
+	if errno := r.Resize(8, 0); zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("Resize errno = %v, want EBADF", zcall.Errno(errno))
+	}
+	if r.SQ() != nil || r.CQ() != nil || r.Rings() != nil {
+		t.Fatal("accessors of a closed ring are not nil")
+	}
+}
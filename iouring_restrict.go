@@ -0,0 +1,79 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// RestrictionBuilder collects the allow list of a sandboxed io_uring
+// instance. Once restrictions are registered, submitting an opcode or SQE
+// flag outside the list fails the request with EACCES, and a register
+// opcode outside the list fails with EACCES.
+//
+// Restrictions can only be registered once, on a ring created with
+// IORING_SETUP_R_DISABLED. The zero value is an empty list; the kernel
+// rejects registering an empty list with EINVAL.
+type RestrictionBuilder struct {
+	res []IoUringRestriction
+}
+
+// AllowOp allows the SQE opcodes ops.
+func (b *RestrictionBuilder) AllowOp(ops ...uint8) *RestrictionBuilder {
+	for _, op := range ops {
+		b.add(IORING_RESTRICTION_SQE_OP, op)
+	}
+	return b
+}
+
+// AllowRegisterOp allows the io_uring_register opcodes ops.
+func (b *RestrictionBuilder) AllowRegisterOp(ops ...uint8) *RestrictionBuilder {
+	for _, op := range ops {
+		b.add(IORING_RESTRICTION_REGISTER_OP, op)
+	}
+	return b
+}
+
+// AllowSQEFlags allows the IOSQE_* flags in flags to be set on SQEs.
+func (b *RestrictionBuilder) AllowSQEFlags(flags uint8) *RestrictionBuilder {
+	b.add(IORING_RESTRICTION_SQE_FLAGS_ALLOWED, flags)
+	return b
+}
+
+// RequireSQEFlags requires every SQE to set the IOSQE_* flags in flags.
+func (b *RestrictionBuilder) RequireSQEFlags(flags uint8) *RestrictionBuilder {
+	b.add(IORING_RESTRICTION_SQE_FLAGS_REQUIRED, flags)
+	return b
+}
+
+// Restrictions returns the collected entries.
+func (b *RestrictionBuilder) Restrictions() []IoUringRestriction {
+	return b.res
+}
+
+// Apply registers the restrictions with the disabled io_uring instance fd
+// through IORING_REGISTER_RESTRICTIONS.
+func (b *RestrictionBuilder) Apply(fd uintptr) (errno uintptr) {
+	var arg unsafe.Pointer
+	if len(b.res) > 0 {
+		arg = unsafe.Pointer(&b.res[0])
+	}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_RESTRICTIONS, arg, uintptr(len(b.res)))
+	return errno
+}
+
+// ApplyAndEnable registers the restrictions and then enables the ring with
+// IORING_REGISTER_ENABLE_RINGS, after which it accepts submissions.
+func (b *RestrictionBuilder) ApplyAndEnable(fd uintptr) (errno uintptr) {
+	if errno = b.Apply(fd); errno != 0 {
+		return errno
+	}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_ENABLE_RINGS, nil, 0)
+	return errno
+}
+
+func (b *RestrictionBuilder) add(opcode uint16, arg uint8) {
+	b.res = append(b.res, IoUringRestriction{Opcode: opcode, Arg: arg})
+}
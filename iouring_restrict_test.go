@@ -0,0 +1,101 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestIoUringRestrictionLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringRestriction{}); got != 16 {
+		t.Errorf("sizeof(IoUringRestriction) = %d, want 16", got)
+	}
+	if got := unsafe.Offsetof(zcall.IoUringRestriction{}.Arg); got != 2 {
+		t.Errorf("offsetof(IoUringRestriction.Arg) = %d, want 2", got)
+	}
+}
+
+func TestRestrictionBuilder(t *testing.T) {
+	var b zcall.RestrictionBuilder
+	b.AllowOp(zcall.IORING_OP_NOP, zcall.IORING_OP_WRITE).
+		AllowRegisterOp(zcall.IORING_REGISTER_PROBE).
+		AllowSQEFlags(zcall.IOSQE_IO_LINK).
+		RequireSQEFlags(0)
+	want := []zcall.IoUringRestriction{
+		{Opcode: zcall.IORING_RESTRICTION_SQE_OP, Arg: zcall.IORING_OP_NOP},
+		{Opcode: zcall.IORING_RESTRICTION_SQE_OP, Arg: zcall.IORING_OP_WRITE},
+		{Opcode: zcall.IORING_RESTRICTION_REGISTER_OP, Arg: zcall.IORING_REGISTER_PROBE},
+		{Opcode: zcall.IORING_RESTRICTION_SQE_FLAGS_ALLOWED, Arg: zcall.IOSQE_IO_LINK},
+		{Opcode: zcall.IORING_RESTRICTION_SQE_FLAGS_REQUIRED},
+	}
+	got := b.Restrictions()
+	if len(got) != len(want) {
+		t.Fatalf("Restrictions = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Restrictions[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRestrictionsEnforced(t *testing.T) {
+	r := newTestRing(t, 8, zcall.IORING_SETUP_R_DISABLED)
+	_, wfd := testPipe(t)
+
+	// A disabled ring rejects submissions.
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if _, errno := zcall.IoUringEnter(r.fd, uintptr(r.sq.Flush()), 0, 0, nil, 0); zcall.Errno(errno) != zcall.EBADFD {
+		t.Fatalf("IoUringEnter on a disabled ring errno = %v, want EBADFD", zcall.Errno(errno))
+	}
+
+	var b zcall.RestrictionBuilder
+	b.AllowOp(zcall.IORING_OP_NOP, zcall.IORING_OP_WRITE).
+		AllowRegisterOp(zcall.IORING_REGISTER_PROBE).
+		AllowSQEFlags(zcall.IOSQE_IO_LINK)
+	if errno := b.ApplyAndEnable(r.fd); errno != 0 {
+		t.Fatalf("ApplyAndEnable failed: %v", zcall.Errno(errno))
+	}
+	r.enter(1)
+	cqe := r.next()
+	expectRes(t, "NOP queued before enabling", cqe, 0)
+
+	expectRes(t, "NOP", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) }), 0)
+	expectRes(t, "WRITE", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepWrite(sqe, wfd, []byte("ok"), 0) }), 2)
+	expectRes(t, "NOP with allowed flag", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+	}), 0)
+
+	// Forbidden opcode and flag.
+	buf := make([]byte, 4)
+	expectRes(t, "READ", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepRead(sqe, wfd, buf, 0) }), -int32(zcall.EACCES))
+	expectRes(t, "NOP with forbidden flag", r.run(func(sqe *zcall.IoUringSqe) {
+		zcall.PrepNop(sqe)
+		sqe.Flags |= zcall.IOSQE_ASYNC
+	}), -int32(zcall.EACCES))
+
+	// Register opcodes.
+	if _, errno := zcall.ProbeRing(r.fd); errno != 0 {
+		t.Fatalf("ProbeRing failed: %v", zcall.Errno(errno))
+	}
+	if _, errno := zcall.RegisterFileTable(r.fd, 1); zcall.Errno(errno) != zcall.EACCES {
+		t.Fatalf("RegisterFileTable errno = %v, want EACCES", zcall.Errno(errno))
+	}
+}
+
+func TestRestrictionsRequireDisabledRing(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	var b zcall.RestrictionBuilder
+	b.AllowOp(zcall.IORING_OP_NOP)
+	if errno := b.Apply(r.fd); zcall.Errno(errno) != zcall.EBADFD {
+		t.Fatalf("Apply on an enabled ring errno = %v, want EBADFD", zcall.Errno(errno))
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// RingHandle names an io_uring instance for io_uring_enter and
+// io_uring_register, either by file descriptor or by its index in the
+// registered ring table. Calls through a registered index skip the file
+// descriptor lookup the kernel otherwise performs on every call.
+//
+// The registered ring table belongs to the calling thread: a registered
+// handle is valid only on the OS thread that registered it, so goroutines
+// using one must call runtime.LockOSThread first.
+type RingHandle struct {
+	fd         uintptr
+	registered bool
+}
+
+// RingFd returns a handle that names the ring by its file descriptor fd.
+func RingFd(fd uintptr) RingHandle {
+	return RingHandle{fd: fd}
+}
+
+// RegisteredRing returns a handle for the registered ring index, such as
+// the value IoUringSetup returns for a ring created with
+// IORING_SETUP_REGISTERED_FD_ONLY.
+func RegisteredRing(index uintptr) RingHandle {
+	return RingHandle{fd: index, registered: true}
+}
+
+// RegisterRingFd registers the ring file descriptor fd with
+// IORING_REGISTER_RING_FDS and returns a handle for the registered index.
+// fd stays open and usable; close it once the handle is the only name
+// needed.
+func RegisterRingFd(fd uintptr) (h RingHandle, errno uintptr) {
+	up := IoUringRsrcUpdate{Offset: ^uint32(0), Data: uint64(fd)}
+	if _, errno = IoUringRegister(fd, IORING_REGISTER_RING_FDS, unsafe.Pointer(&up), 1); errno != 0 {
+		return RingHandle{}, errno
+	}
+	return RegisteredRing(uintptr(up.Offset)), 0
+}
+
+// Fd returns the value to pass as the fd argument of io_uring_enter and
+// io_uring_register: the file descriptor or the registered index.
+func (h RingHandle) Fd() uintptr {
+	return h.fd
+}
+
+// Registered reports whether the handle is a registered ring index.
+func (h RingHandle) Registered() bool {
+	return h.registered
+}
+
+// EnterFlags returns the io_uring_enter flags the handle requires,
+// IORING_ENTER_REGISTERED_RING for a registered index.
+func (h RingHandle) EnterFlags() uintptr {
+	if h.registered {
+		return IORING_ENTER_REGISTERED_RING
+	}
+	return 0
+}
+
+// Enter calls IoUringEnter on the ring, adding the flags the handle requires.
+func (h RingHandle) Enter(toSubmit, minComplete, flags uintptr, sig unsafe.Pointer, sigsetSize uintptr) (r1 uintptr, errno uintptr) {
+	return IoUringEnter(h.fd, toSubmit, minComplete, flags|h.EnterFlags(), sig, sigsetSize)
+}
+
+// Register calls IoUringRegister on the ring, adding
+// IORING_REGISTER_USE_REGISTERED_RING for a registered index.
+func (h RingHandle) Register(opcode uintptr, arg unsafe.Pointer, nrArgs uintptr) (r1 uintptr, errno uintptr) {
+	if h.registered {
+		opcode |= IORING_REGISTER_USE_REGISTERED_RING
+	}
+	return IoUringRegister(h.fd, opcode, arg, nrArgs)
+}
+
+// Unregister removes a registered index with IORING_UNREGISTER_RING_FDS.
+// For a ring created with IORING_SETUP_REGISTERED_FD_ONLY, this drops the
+// last reference and destroys the ring. It is a no-op for a plain
+// file descriptor handle.
+func (h RingHandle) Unregister() (errno uintptr) {
+	if !h.registered {
+		return 0
+	}
+	up := IoUringRsrcUpdate{Offset: uint32(h.fd)}
+	_, errno = IoUringRegister(h.fd, IORING_UNREGISTER_RING_FDS|IORING_REGISTER_USE_REGISTERED_RING, unsafe.Pointer(&up), 1)
+	return errno
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+func TestIoUringRsrcUpdateLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringRsrcUpdate{}); got != 16 {
+		t.Errorf("sizeof(IoUringRsrcUpdate) = %d, want 16", got)
+	}
+}
+
+func TestRingFdHandle(t *testing.T) {
+	h := zcall.RingFd(7)
+	if h.Fd() != 7 || h.Registered() || h.EnterFlags() != 0 {
+		t.Fatalf("RingFd(7) = fd %d, registered %v, flags %#x", h.Fd(), h.Registered(), h.EnterFlags())
+	}
+	if errno := h.Unregister(); errno != 0 {
+		t.Fatalf("Unregister of a plain handle = %v", zcall.Errno(errno))
+	}
+	h = zcall.RegisteredRing(2)
+	if h.Fd() != 2 || !h.Registered() || h.EnterFlags() != zcall.IORING_ENTER_REGISTERED_RING {
+		t.Fatalf("RegisteredRing(2) = fd %d, registered %v, flags %#x", h.Fd(), h.Registered(), h.EnterFlags())
+	}
+}
+
+func TestRegisterRingFd(t *testing.T) {
+	// Registered ring indices belong to the registering thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	r := newTestRing(t, 4, 0)
+	h, errno := zcall.RegisterRingFd(r.fd)
+	if errno != 0 {
+		t.Fatalf("RegisterRingFd failed: %v", zcall.Errno(errno))
+	}
+
+	r.push(1, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if _, errno := h.Enter(uintptr(r.sq.Flush()), 1, zcall.IORING_ENTER_GETEVENTS, nil, 0); errno != 0 {
+		t.Fatalf("Enter failed: %v", zcall.Errno(errno))
+	}
+	if cqe, ok := r.pop(); !ok || cqe.UserData != 1 {
+		t.Fatalf("completion = %+v, %v", cqe, ok)
+	}
+	r.push(2, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if _, errno := r.sq.SubmitAndWait(h.Fd(), 1, h.EnterFlags()); errno != 0 {
+		t.Fatalf("SubmitAndWait through the handle failed: %v", zcall.Errno(errno))
+	}
+	if cqe, ok := r.pop(); !ok || cqe.UserData != 2 {
+		t.Fatalf("completion = %+v, %v", cqe, ok)
+	}
+	var probe zcall.IoUringProbe
+	if _, errno := h.Register(zcall.IORING_REGISTER_PROBE, unsafe.Pointer(&probe), 256); errno != 0 {
+		t.Fatalf("Register through the handle failed: %v", zcall.Errno(errno))
+	}
+
+	// Another thread has its own table, without this ring.
+	done := make(chan uintptr)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		_, errno := h.Enter(0, 0, 0, nil, 0)
+		done <- errno
+	}()
+	if errno := <-done; errno == 0 {
+		t.Fatal("Enter from another thread succeeded")
+	}
+
+	if errno := h.Unregister(); errno != 0 {
+		t.Fatalf("Unregister failed: %v", zcall.Errno(errno))
+	}
+	if _, errno := h.Enter(0, 0, 0, nil, 0); zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("Enter after Unregister errno = %v, want EBADF", zcall.Errno(errno))
+	}
+	// The file descriptor itself is unaffected.
+	if _, errno := zcall.IoUringEnter(r.fd, 0, 0, 0, nil, 0); errno != 0 {
+		t.Fatalf("IoUringEnter on the fd failed: %v", zcall.Errno(errno))
+	}
+}
+
+func TestRegisteredFdOnlyRing(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Such rings have no fd to mmap, so the application provides the memory.
+	p := zcall.IoUringParams{Flags: zcall.IORING_SETUP_REGISTERED_FD_ONLY}
+	index, rings, errno := zcall.IoUringSetupUserMem(4, &p, 0)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_SETUP_REGISTERED_FD_ONLY not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("IoUringSetupUserMem failed: %v", zcall.Errno(errno))
+	}
+	defer rings.Unmap()
+
+	h := zcall.RegisteredRing(index)
+	if _, errno := h.Enter(0, 0, zcall.IORING_ENTER_GETEVENTS, nil, 0); errno != 0 {
+		t.Fatalf("Enter failed: %v", zcall.Errno(errno))
+	}
+	var probe zcall.IoUringProbe
+	if _, errno := h.Register(zcall.IORING_REGISTER_PROBE, unsafe.Pointer(&probe), 256); errno != 0 {
+		t.Fatalf("Register failed: %v", zcall.Errno(errno))
+	}
+
+	// Dropping the only reference destroys the ring.
+	if errno := h.Unregister(); errno != 0 {
+		t.Fatalf("Unregister failed: %v", zcall.Errno(errno))
+	}
+	if _, errno := h.Enter(0, 0, 0, nil, 0); zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("Enter after Unregister errno = %v, want EBADF", zcall.Errno(errno))
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// setupRing creates an io_uring instance, skipping the test when io_uring
+// is unavailable.
+func setupRing(t testing.TB, entries uintptr, p *zcall.IoUringParams) uintptr {
+	t.Helper()
+	fd, errno := zcall.IoUringSetup(entries, unsafe.Pointer(p))
+	if errno != 0 {
+		if zcall.Errno(errno) == zcall.ENOSYS || zcall.Errno(errno) == zcall.EPERM {
+			t.Skip("io_uring not supported on this kernel")
+		}
+		t.Fatalf("IoUringSetup failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { zcall.Close(fd) })
+	return fd
+}
+
+func TestIoUringParamsLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoSqringOffsets{}); got != 40 {
+		t.Errorf("sizeof(IoSqringOffsets) = %d, want 40", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoCqringOffsets{}); got != 40 {
+		t.Errorf("sizeof(IoCqringOffsets) = %d, want 40", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringParams{}); got != 120 {
+		t.Errorf("sizeof(IoUringParams) = %d, want 120", got)
+	}
+}
+
+func TestIoUringMapRings(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags uint32
+	}{
+		{"default", 0},
+		{"no_sqarray", zcall.IORING_SETUP_NO_SQARRAY},
+		{"sqe128_cqe32", zcall.IORING_SETUP_SQE128 | zcall.IORING_SETUP_CQE32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := zcall.IoUringParams{Flags: tt.flags}
+			fd := setupRing(t, 8, &p)
+
+			r, errno := zcall.IoUringMapRings(fd, &p)
+			if errno != 0 {
+				t.Fatalf("IoUringMapRings failed: %v", zcall.Errno(errno))
+			}
+			if *r.SQ.RingEntries != p.SqEntries {
+				t.Errorf("SQ ring entries = %d, want %d", *r.SQ.RingEntries, p.SqEntries)
+			}
+			if *r.SQ.RingMask != p.SqEntries-1 {
+				t.Errorf("SQ ring mask = %d, want %d", *r.SQ.RingMask, p.SqEntries-1)
+			}
+			if *r.CQ.RingEntries != p.CqEntries {
+				t.Errorf("CQ ring entries = %d, want %d", *r.CQ.RingEntries, p.CqEntries)
+			}
+			if *r.SQ.Head != 0 || *r.SQ.Tail != 0 || *r.CQ.Head != 0 || *r.CQ.Tail != 0 {
+				t.Errorf("fresh ring has non-zero head/tail")
+			}
+			if noArray := tt.flags&zcall.IORING_SETUP_NO_SQARRAY != 0; noArray != (r.SQ.Array == nil) {
+				t.Errorf("SQ.Array = %p with NO_SQARRAY=%v", r.SQ.Array, noArray)
+			}
+			wantSQE, wantCQE := uintptr(64), uintptr(16)
+			if tt.flags&zcall.IORING_SETUP_SQE128 != 0 {
+				wantSQE = 128
+			}
+			if tt.flags&zcall.IORING_SETUP_CQE32 != 0 {
+				wantCQE = 32
+			}
+			if r.SQESize != wantSQE || r.CQESize != wantCQE {
+				t.Errorf("entry sizes = %d/%d, want %d/%d", r.SQESize, r.CQESize, wantSQE, wantCQE)
+			}
+			if r.SetupFlags != tt.flags {
+				t.Errorf("SetupFlags = %#x, want %#x", r.SetupFlags, tt.flags)
+			}
+
+			if errno := r.Unmap(); errno != 0 {
+				t.Fatalf("Unmap failed: %v", zcall.Errno(errno))
+			}
+			if r.SQ.Head != nil {
+				t.Errorf("Unmap did not reset views")
+			}
+		})
+	}
+}
+
+func TestIoUringMapRingsBadFd(t *testing.T) {
+	p := zcall.IoUringParams{SqEntries: 8, CqEntries: 16, Features: zcall.IORING_FEAT_SINGLE_MMAP}
+	_, errno := zcall.IoUringMapRings(^uintptr(0), &p)
+	if zcall.Errno(errno) != zcall.EBADF {
+		t.Fatalf("IoUringMapRings(-1) errno = %v, want EBADF", zcall.Errno(errno))
+	}
+}
+
+// testRing is a minimal single-threaded ring driver for exercising
+// prepared SQEs against the kernel.
+type testRing struct {
+	t     testing.TB
+	fd    uintptr
+	rings zcall.IoUringRings
+	sq    *zcall.SubmissionQueue
+	cq    *zcall.CompletionQueue
+}
+
+func newTestRing(t testing.TB, entries uintptr, flags uint32) *testRing {
+	t.Helper()
+	return newTestRingParams(t, entries, &zcall.IoUringParams{Flags: flags})
+}
+
+// newTestRingParams is newTestRing with full control over the setup parameters.
+func newTestRingParams(t testing.TB, entries uintptr, p *zcall.IoUringParams) *testRing {
+	t.Helper()
+	fd := setupRing(t, entries, p)
+	r, errno := zcall.IoUringMapRings(fd, p)
+	if errno != 0 {
+		t.Fatalf("IoUringMapRings failed: %v", zcall.Errno(errno))
+	}
+	tr := &testRing{t: t, fd: fd, rings: r}
+	tr.sq = zcall.NewSubmissionQueue(&tr.rings)
+	tr.cq = zcall.NewCompletionQueue(&tr.rings)
+	t.Cleanup(func() { tr.rings.Unmap() })
+	return tr
+}
+
+// push prepares the next SQE with prep and tags it with userData.
+func (r *testRing) push(userData uint64, prep func(sqe *zcall.IoUringSqe)) {
+	r.t.Helper()
+	sqe := r.sq.NextSQE()
+	if sqe == nil {
+		r.t.Fatal("submission queue full")
+	}
+	prep(sqe)
+	sqe.UserData = userData
+}
+
+// enter flushes pending SQEs and waits until minComplete completions are
+// ready. io_uring_enter returns the submitted count even when a signal cut
+// the wait short, so the wait is repeated until the CQ holds enough.
+func (r *testRing) enter(minComplete uintptr) {
+	r.t.Helper()
+	toSubmit := uintptr(r.sq.Flush())
+	for {
+		_, errno := zcall.IoUringEnter(r.fd, toSubmit, minComplete, zcall.IORING_ENTER_GETEVENTS, nil, 0)
+		if errno != 0 && zcall.Errno(errno) != zcall.EINTR {
+			r.t.Fatalf("IoUringEnter failed: %v", zcall.Errno(errno))
+		}
+		if errno == 0 {
+			toSubmit = 0
+		}
+		if uintptr(r.cq.CQReady()) >= minComplete {
+			return
+		}
+	}
+}
+
+// pop removes the next CQE, if any.
+func (r *testRing) pop() (cqe zcall.IoUringCqe, ok bool) {
+	c := r.cq.PeekCQE()
+	if c == nil {
+		return cqe, false
+	}
+	cqe = *c
+	r.cq.SeenCQE()
+	return cqe, true
+}
+
+// next removes the next CQE, failing the test if none is ready.
+func (r *testRing) next() zcall.IoUringCqe {
+	r.t.Helper()
+	cqe, ok := r.pop()
+	if !ok {
+		r.t.Fatal("no completion after IoUringEnter")
+	}
+	return cqe
+}
+
+// run submits a single prepared SQE and returns its completion.
+func (r *testRing) run(prep func(sqe *zcall.IoUringSqe)) zcall.IoUringCqe {
+	r.t.Helper()
+	r.push(1, prep)
+	r.enter(1)
+	return r.next()
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// ringHeaderMax bounds the size of the kernel struct io_rings that precedes
+// the CQE array, including cache line padding.
+const ringHeaderMax = 1024
+
+// IoUringSetupUserMem creates an io_uring instance with IORING_SETUP_NO_MMAP,
+// placing the SQE array and the rings in memory the application maps itself
+// rather than in kernel memory mapped through the ring fd. p is used as for
+// IoUringSetup; its user_addr fields are filled in here. A nonzero
+// hugePageSize backs the memory with MAP_HUGETLB pages of that size, which
+// must have been reserved by the administrator, and small rings fit in a
+// single huge page.
+//
+// When p.Flags include IORING_SETUP_REGISTERED_FD_ONLY, fd is a registered
+// ring index for use with RegisteredRing instead of a file descriptor.
+//
+// The kernel pins the memory for the lifetime of the ring, so r.Unmap must
+// only be called once the ring is closed. On failure, the memory is
+// released.
+func IoUringSetupUserMem(entries uint32, p *IoUringParams, hugePageSize uintptr) (fd uintptr, r IoUringRings, errno uintptr) {
+	if entries == 0 {
+		return 0, IoUringRings{}, uintptr(EINVAL)
+	}
+	p.Flags |= IORING_SETUP_NO_MMAP
+	r.SetupFlags = p.Flags
+	r.SQESize = unsafe.Sizeof(IoUringSqe{})
+	if p.Flags&IORING_SETUP_SQE128 != 0 {
+		r.SQESize *= 2
+	}
+	r.CQESize = unsafe.Sizeof(IoUringCqe{})
+	if p.Flags&IORING_SETUP_CQE32 != 0 {
+		r.CQESize *= 2
+	}
+
+	// Size for the entry counts the kernel will settle on. Larger requests
+	// are either clamped or rejected by the kernel before it touches the
+	// memory.
+	sqEntries := roundPow2(min(entries, 32768))
+	cqEntries := 2 * sqEntries
+	if p.Flags&IORING_SETUP_CQSIZE != 0 {
+		cqEntries = roundPow2(min(max(p.CqEntries, sqEntries), 65536))
+	}
+	sqesSize := uintptr(sqEntries) * r.SQESize
+	ringSize := ringHeaderMax + uintptr(cqEntries)*r.CQESize
+	if p.Flags&IORING_SETUP_NO_SQARRAY == 0 {
+		ringSize += uintptr(sqEntries) * 4
+	}
+	sqesSize = (sqesSize + maxPageSize - 1) &^ (maxPageSize - 1)
+	ringSize = (ringSize + maxPageSize - 1) &^ (maxPageSize - 1)
+	size := sqesSize + ringSize
+	flags := uintptr(MAP_PRIVATE | MAP_ANONYMOUS | MAP_POPULATE)
+	if hugePageSize != 0 {
+		var huge uintptr
+		if size, huge, errno = hugeMmap(size, hugePageSize); errno != 0 {
+			return 0, IoUringRings{}, errno
+		}
+		flags |= huge
+	}
+	mem, errno := Mmap(nil, size, PROT_READ|PROT_WRITE, flags, ^uintptr(0), 0)
+	if errno != 0 {
+		return 0, IoUringRings{}, errno
+	}
+	rings := unsafe.Add(mem, sqesSize)
+	p.SqOff.UserAddr = uint64(uintptr(mem))
+	p.CqOff.UserAddr = uint64(uintptr(rings))
+	fd, errno = IoUringSetup(uintptr(entries), unsafe.Pointer(p))
+	if errno != 0 {
+		Munmap(mem, size)
+		return 0, IoUringRings{}, errno
+	}
+
+	// One mapping backs everything; Unmap releases it through sqes.
+	r.sqes, r.sqesSize = mem, size
+	r.sqRing, r.cqRing = rings, rings
+	r.initViews(p)
+	return fd, r, 0
+}
+
+// roundPow2 rounds n up to a power of two.
+func roundPow2(n uint32) uint32 {
+	p := uint32(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// RegisterMemRegion registers the memory region described by rd with the
+// io_uring instance fd through IORING_REGISTER_MEM_REGION. flags may be
+// IORING_MEM_REGION_REG_WAIT_ARG to use the region for registered wait
+// arguments, which requires a ring created with IORING_SETUP_R_DISABLED.
+//
+// With IORING_MEM_REGION_TYPE_USER in rd.Flags, the region is the
+// application memory at rd.UserAddr, which must be page aligned. Otherwise
+// the kernel allocates rd.Size bytes itself and writes the offset at which
+// to Mmap them from the ring fd to rd.MmapOffset.
+//
+// An instance has at most one such region, registered for its lifetime.
+func RegisterMemRegion(fd uintptr, rd *IoUringRegionDesc, flags uint64) (errno uintptr) {
+	reg := IoUringMemRegionReg{
+		RegionUptr: uint64(uintptr(unsafe.Pointer(rd))),
+		Flags:      flags,
+	}
+	_, errno = IoUringRegister(fd, IORING_REGISTER_MEM_REGION, unsafe.Pointer(&reg), 1)
+	return errno
+}
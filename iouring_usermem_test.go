@@ -0,0 +1,168 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// newTestUserMemRing is newTestRingParams for a ring in application memory.
+func newTestUserMemRing(t *testing.T, entries uint32, p *zcall.IoUringParams, hugePageSize uintptr) *testRing {
+	t.Helper()
+	fd, rings, errno := zcall.IoUringSetupUserMem(entries, p, hugePageSize)
+	switch zcall.Errno(errno) {
+	case 0:
+	case zcall.ENOSYS, zcall.EPERM:
+		t.Skip("io_uring not supported on this kernel")
+	case zcall.EINVAL:
+		t.Skip("IORING_SETUP_NO_MMAP not supported on this kernel")
+	default:
+		t.Fatalf("IoUringSetupUserMem failed: %v", zcall.Errno(errno))
+	}
+	tr := &testRing{t: t, fd: fd, rings: rings}
+	tr.sq = zcall.NewSubmissionQueue(&tr.rings)
+	tr.cq = zcall.NewCompletionQueue(&tr.rings)
+	// The ring must be closed before its memory is released.
+	t.Cleanup(func() { tr.rings.Unmap() })
+	t.Cleanup(func() { zcall.Close(fd) })
+	return tr
+}
+
+func TestIoUringSetupUserMem(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		flags uint32
+	}{
+		{"default", 0},
+		{"sqe128_cqe32", zcall.IORING_SETUP_SQE128 | zcall.IORING_SETUP_CQE32},
+		{"no_sqarray", zcall.IORING_SETUP_NO_SQARRAY},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := zcall.IoUringParams{Flags: tt.flags}
+			r := newTestUserMemRing(t, 64, &p, 0)
+			if p.Flags&zcall.IORING_SETUP_NO_MMAP == 0 {
+				t.Fatal("IORING_SETUP_NO_MMAP not set")
+			}
+			if *r.rings.SQ.RingEntries != 64 || *r.rings.CQ.RingEntries != 128 {
+				t.Fatalf("ring entries = %d/%d, want 64/128", *r.rings.SQ.RingEntries, *r.rings.CQ.RingEntries)
+			}
+			// Fill the whole SQ to touch the end of the SQE array.
+			for i := range uint64(64) {
+				r.push(i, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+			}
+			r.enter(64)
+			for i := range uint64(64) {
+				cqe, ok := r.pop()
+				if !ok || cqe.UserData != i || cqe.Res != 0 {
+					t.Fatalf("completion %d = %+v, %v", i, cqe, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestIoUringSetupUserMemCQSize(t *testing.T) {
+	p := zcall.IoUringParams{Flags: zcall.IORING_SETUP_CQSIZE, CqEntries: 1000}
+	r := newTestUserMemRing(t, 8, &p, 0)
+	if got := r.cq.Entries(); got != 1024 {
+		t.Fatalf("CQ entries = %d, want 1024", got)
+	}
+	expectRes(t, "NOP", r.run(func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) }), 0)
+}
+
+func TestIoUringSetupUserMemHugePages(t *testing.T) {
+	p := zcall.IoUringParams{}
+	fd, rings, errno := zcall.IoUringSetupUserMem(256, &p, 2<<20)
+	if zcall.Errno(errno) == zcall.ENOMEM {
+		t.Skip("no huge pages reserved")
+	}
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_SETUP_NO_MMAP or 2 MiB huge pages not supported")
+	}
+	if errno != 0 {
+		t.Fatalf("IoUringSetupUserMem failed: %v", zcall.Errno(errno))
+	}
+	tr := &testRing{t: t, fd: fd, rings: rings}
+	tr.sq = zcall.NewSubmissionQueue(&tr.rings)
+	tr.cq = zcall.NewCompletionQueue(&tr.rings)
+	expectRes(t, "NOP", tr.run(func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) }), 0)
+	zcall.Close(fd)
+	if errno := rings.Unmap(); errno != 0 {
+		t.Fatalf("Unmap failed: %v", zcall.Errno(errno))
+	}
+}
+
+func TestIoUringSetupUserMemZeroEntries(t *testing.T) {
+	var p zcall.IoUringParams
+	if _, _, errno := zcall.IoUringSetupUserMem(0, &p, 0); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("IoUringSetupUserMem(0) errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+	if _, _, errno := zcall.IoUringSetupUserMem(4, &p, 3<<20); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("huge page size 3 MiB errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+func TestRegisterMemRegionKernel(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	const size = 64 << 10
+	rd := zcall.IoUringRegionDesc{Size: size}
+	errno := zcall.RegisterMemRegion(r.fd, &rd, 0)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_MEM_REGION not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("RegisterMemRegion failed: %v", zcall.Errno(errno))
+	}
+	if rd.MmapOffset == 0 {
+		t.Fatal("kernel region has no mmap offset")
+	}
+	mem, errno := zcall.Mmap(nil, size, zcall.PROT_READ|zcall.PROT_WRITE, zcall.MAP_SHARED|zcall.MAP_POPULATE, r.fd, uintptr(rd.MmapOffset))
+	if errno != 0 {
+		t.Fatalf("Mmap of region failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Munmap(mem, size)
+	region := unsafe.Slice((*byte)(mem), size)
+	region[0], region[size-1] = 1, 2
+
+	// Only one region per ring.
+	if errno := zcall.RegisterMemRegion(r.fd, &zcall.IoUringRegionDesc{Size: size}, 0); zcall.Errno(errno) != zcall.EBUSY {
+		t.Fatalf("second RegisterMemRegion errno = %v, want EBUSY", zcall.Errno(errno))
+	}
+}
+
+func TestRegisterMemRegionUser(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	const size = 64 << 10
+	mem, errno := zcall.Mmap(nil, size, zcall.PROT_READ|zcall.PROT_WRITE, zcall.MAP_PRIVATE|zcall.MAP_ANONYMOUS, ^uintptr(0), 0)
+	if errno != 0 {
+		t.Fatalf("Mmap failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() { zcall.Munmap(mem, size) })
+	rd := zcall.IoUringRegionDesc{
+		UserAddr: uint64(uintptr(mem)),
+		Size:     size,
+		Flags:    zcall.IORING_MEM_REGION_TYPE_USER,
+	}
+	errno = zcall.RegisterMemRegion(r.fd, &rd, 0)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_REGISTER_MEM_REGION not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("RegisterMemRegion failed: %v", zcall.Errno(errno))
+	}
+
+	// Wait arguments need a disabled ring.
+	r2 := newTestRing(t, 4, 0)
+	rd.UserAddr = 0
+	rd.Flags = 0
+	if errno := zcall.RegisterMemRegion(r2.fd, &rd, zcall.IORING_MEM_REGION_REG_WAIT_ARG); errno == 0 {
+		t.Fatal("RegisterMemRegion accepted wait arguments on an enabled ring")
+	}
+}
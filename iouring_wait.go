@@ -0,0 +1,120 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+import "unsafe"
+
+// sigsetSize is the size of the kernel sigset_t.
+const sigsetSize = 8
+
+// maxPageSize is the largest page size of the supported targets. Memory
+// shared with the kernel is sized in multiples of it so that it is page
+// aligned whatever the running page size.
+const maxPageSize = 64 << 10
+
+// IoUringWait waits for minComplete completions on the io_uring instance fd
+// using the IORING_ENTER_EXT_ARG form of io_uring_enter, without arming a
+// timeout SQE. A non-nil timeout bounds the wait; it is relative unless
+// flags include IORING_ENTER_ABS_TIMER, in which case it is an absolute time
+// on the ring clock (CLOCK_MONOTONIC unless changed with
+// IORING_REGISTER_CLOCK). A non-nil sigmask points to a sigset_t installed
+// for the duration of the wait. flags may also include
+// IORING_ENTER_REGISTERED_RING.
+//
+// When the timeout expires before minComplete completions are available,
+// errno is ETIME.
+func IoUringWait(fd, minComplete uintptr, timeout *Timespec, sigmask unsafe.Pointer, flags uintptr) (r1 uintptr, errno uintptr) {
+	arg := IoUringGeteventsArg{Ts: addrOf(unsafe.Pointer(timeout))}
+	if sigmask != nil {
+		arg.Sigmask = addrOf(sigmask)
+		arg.SigmaskSz = sigsetSize
+	}
+	flags |= IORING_ENTER_GETEVENTS | IORING_ENTER_EXT_ARG
+	return IoUringEnter(fd, 0, minComplete, flags, unsafe.Pointer(&arg), unsafe.Sizeof(arg))
+}
+
+// WaitRegion is an array of IoUringRegWait slots registered with an
+// io_uring instance through IORING_REGISTER_MEM_REGION. A wait then names
+// a slot instead of passing its arguments, so the kernel reads them from
+// memory it has already pinned.
+//
+// The kernel only accepts a wait region while the ring is disabled: create
+// the ring with IORING_SETUP_R_DISABLED, register the region, then enable
+// the ring with IORING_REGISTER_ENABLE_RINGS. A region stays registered for
+// the lifetime of the ring; Close must not be called before the ring fd is
+// closed.
+type WaitRegion struct {
+	fd    uintptr
+	mem   unsafe.Pointer
+	size  uintptr
+	slots []IoUringRegWait
+}
+
+// RegisterWaitRegion maps room for at least nr wait slots and registers it
+// with the io_uring instance fd.
+func RegisterWaitRegion(fd uintptr, nr uint32) (w *WaitRegion, errno uintptr) {
+	if nr == 0 {
+		return nil, uintptr(EINVAL)
+	}
+	size := uintptr(nr) * unsafe.Sizeof(IoUringRegWait{})
+	size = (size + maxPageSize - 1) &^ (maxPageSize - 1)
+	mem, errno := Mmap(nil, size, PROT_READ|PROT_WRITE, MAP_PRIVATE|MAP_ANONYMOUS|MAP_POPULATE, ^uintptr(0), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	rd := IoUringRegionDesc{
+		UserAddr: uint64(uintptr(mem)),
+		Size:     uint64(size),
+		Flags:    IORING_MEM_REGION_TYPE_USER,
+	}
+	if errno = RegisterMemRegion(fd, &rd, IORING_MEM_REGION_REG_WAIT_ARG); errno != 0 {
+		Munmap(mem, size)
+		return nil, errno
+	}
+	n := size / unsafe.Sizeof(IoUringRegWait{})
+	return &WaitRegion{
+		fd:    fd,
+		mem:   mem,
+		size:  size,
+		slots: unsafe.Slice((*IoUringRegWait)(mem), n),
+	}, 0
+}
+
+// Len returns the number of slots in the region.
+func (w *WaitRegion) Len() int {
+	return len(w.slots)
+}
+
+// Slot returns slot index for the caller to fill in before a Wait that
+// names it. Set IORING_REG_WAIT_TS in Flags for Ts to take effect.
+func (w *WaitRegion) Slot(index uint32) *IoUringRegWait {
+	return &w.slots[index]
+}
+
+// Wait waits for minComplete completions using the arguments in slot index,
+// through io_uring_enter with IORING_ENTER_EXT_ARG_REG. flags may add
+// IORING_ENTER_ABS_TIMER or IORING_ENTER_REGISTERED_RING.
+func (w *WaitRegion) Wait(minComplete uintptr, index uint32, flags uintptr) (r1 uintptr, errno uintptr) {
+	if uintptr(index) >= uintptr(len(w.slots)) {
+		return 0, uintptr(EINVAL)
+	}
+	// The kernel takes the byte offset of the slot in place of a pointer.
+	off := uintptr(index) * unsafe.Sizeof(IoUringRegWait{})
+	flags |= IORING_ENTER_GETEVENTS | IORING_ENTER_EXT_ARG | IORING_ENTER_EXT_ARG_REG
+	return Syscall6(SYS_IO_URING_ENTER, w.fd, 0, minComplete, flags, off, unsafe.Sizeof(IoUringRegWait{}))
+}
+
+// Close releases the region memory. The ring it was registered with must
+// already be closed.
+func (w *WaitRegion) Close() (errno uintptr) {
+	if w.mem == nil {
+		return 0
+	}
+	errno = Munmap(w.mem, w.size)
+	w.mem, w.slots = nil, nil
+	return errno
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// retryEINTR repeats wait while it is interrupted by runtime signals.
+func retryEINTR(wait func() (uintptr, uintptr)) (r1, errno uintptr) {
+	for {
+		r1, errno = wait()
+		if zcall.Errno(errno) != zcall.EINTR {
+			return r1, errno
+		}
+	}
+}
+
+func TestIoUringWaitLayout(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.IoUringGeteventsArg{}); got != 24 {
+		t.Errorf("sizeof(IoUringGeteventsArg) = %d, want 24", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringRegWait{}); got != 64 {
+		t.Errorf("sizeof(IoUringRegWait) = %d, want 64", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringRegionDesc{}); got != 64 {
+		t.Errorf("sizeof(IoUringRegionDesc) = %d, want 64", got)
+	}
+	if got := unsafe.Sizeof(zcall.IoUringMemRegionReg{}); got != 32 {
+		t.Errorf("sizeof(IoUringMemRegionReg) = %d, want 32", got)
+	}
+}
+
+func TestIoUringWaitTimeout(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	ts := zcall.Timespec{Nsec: int64(20 * time.Millisecond)}
+	start := time.Now()
+	_, errno := retryEINTR(func() (uintptr, uintptr) { return zcall.IoUringWait(r.fd, 1, &ts, nil, 0) })
+	if zcall.Errno(errno) != zcall.ETIME {
+		t.Fatalf("IoUringWait errno = %v, want ETIME", zcall.Errno(errno))
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Fatalf("IoUringWait returned after %v, want about 20ms", elapsed)
+	}
+}
+
+func TestIoUringWaitAbsTimer(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	// An absolute time of zero has already passed.
+	var ts zcall.Timespec
+	_, errno := zcall.IoUringWait(r.fd, 1, &ts, nil, zcall.IORING_ENTER_ABS_TIMER)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("IORING_ENTER_ABS_TIMER not supported on this kernel")
+	}
+	if zcall.Errno(errno) != zcall.ETIME {
+		t.Fatalf("IoUringWait errno = %v, want ETIME", zcall.Errno(errno))
+	}
+}
+
+func TestIoUringWaitCompletion(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	r.push(42, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if _, errno := zcall.IoUringEnter(r.fd, uintptr(r.sq.Flush()), 0, 0, nil, 0); errno != 0 {
+		t.Fatalf("IoUringEnter failed: %v", zcall.Errno(errno))
+	}
+
+	var sigmask uint64
+	ts := zcall.Timespec{Sec: 5}
+	_, errno := retryEINTR(func() (uintptr, uintptr) { return zcall.IoUringWait(r.fd, 1, &ts, unsafe.Pointer(&sigmask), 0) })
+	if errno != 0 {
+		t.Fatalf("IoUringWait failed: %v", zcall.Errno(errno))
+	}
+	if cqe, ok := r.pop(); !ok || cqe.UserData != 42 {
+		t.Fatalf("completion = %+v, %v; want UserData 42", cqe, ok)
+	}
+}
+
+func TestWaitRegion(t *testing.T) {
+	r := newTestRing(t, 4, zcall.IORING_SETUP_R_DISABLED)
+	w, errno := zcall.RegisterWaitRegion(r.fd, 2)
+	if zcall.Errno(errno) == zcall.EINVAL {
+		t.Skip("registered wait regions not supported on this kernel")
+	}
+	if errno != 0 {
+		t.Fatalf("RegisterWaitRegion failed: %v", zcall.Errno(errno))
+	}
+	if w.Len() < 2 {
+		t.Fatalf("Len = %d, want at least 2", w.Len())
+	}
+	if _, errno := zcall.IoUringRegister(r.fd, zcall.IORING_REGISTER_ENABLE_RINGS, nil, 0); errno != 0 {
+		t.Fatalf("IORING_REGISTER_ENABLE_RINGS failed: %v", zcall.Errno(errno))
+	}
+
+	slot := w.Slot(1)
+	slot.Ts = zcall.Timespec{Nsec: int64(10 * time.Millisecond)}
+	slot.Flags = zcall.IORING_REG_WAIT_TS
+	start := time.Now()
+	if _, errno := retryEINTR(func() (uintptr, uintptr) { return w.Wait(1, 1, 0) }); zcall.Errno(errno) != zcall.ETIME {
+		t.Fatalf("Wait errno = %v, want ETIME", zcall.Errno(errno))
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Fatalf("Wait returned after %v, want about 10ms", elapsed)
+	}
+
+	r.push(7, func(sqe *zcall.IoUringSqe) { zcall.PrepNop(sqe) })
+	if _, errno := zcall.IoUringEnter(r.fd, uintptr(r.sq.Flush()), 0, 0, nil, 0); errno != 0 {
+		t.Fatalf("IoUringEnter failed: %v", zcall.Errno(errno))
+	}
+	if _, errno := retryEINTR(func() (uintptr, uintptr) { return w.Wait(1, 1, 0) }); errno != 0 {
+		t.Fatalf("Wait failed: %v", zcall.Errno(errno))
+	}
+	if cqe, ok := r.pop(); !ok || cqe.UserData != 7 {
+		t.Fatalf("completion = %+v, %v; want UserData 7", cqe, ok)
+	}
+
+	if _, errno := w.Wait(1, uint32(w.Len()), 0); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("Wait out of range errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+func TestRegisterWaitRegionEnabledRing(t *testing.T) {
+	r := newTestRing(t, 4, 0)
+	if _, errno := zcall.RegisterWaitRegion(r.fd, 1); errno == 0 {
+		t.Fatal("RegisterWaitRegion on an enabled ring succeeded")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall
+
+// ZCResult is the outcome of a zero-copy send.
+type ZCResult struct {
+	// N is the number of bytes sent, or 0 on error.
+	N int32
+	// Errno is the error of a failed send, or 0.
+	Errno uintptr
+	// Copied is set when the kernel fell back to copying the data
+	// (IORING_NOTIF_USAGE_ZC_COPIED). It is only reported for sends
+	// prepared with IORING_SEND_ZC_REPORT_USAGE.
+	Copied bool
+}
+
+// zcSend is the state of one in-flight zero-copy send.
+type zcSend struct {
+	buf      []byte
+	result   ZCResult
+	done     bool
+	notified bool
+}
+
+// ZCTracker pairs the two completions of IORING_OP_SEND_ZC and
+// IORING_OP_SENDMSG_ZC requests. The first completion carries the send
+// result; the buffer stays in use by the kernel until the second,
+// IORING_CQE_F_NOTIF completion arrives. The tracker keeps each buffer
+// referenced until then and hands it to the release function only once
+// the kernel no longer uses it.
+//
+// A ZCTracker must be used by a single goroutine at a time.
+type ZCTracker struct {
+	inflight map[uint64]*zcSend
+	free     []*zcSend
+	release  func(userData uint64, buf []byte, r ZCResult)
+}
+
+// NewZCTracker returns a tracker that calls release for every tracked send
+// once both its result and its buffer are final.
+func NewZCTracker(release func(userData uint64, buf []byte, r ZCResult)) *ZCTracker {
+	return &ZCTracker{inflight: make(map[uint64]*zcSend), release: release}
+}
+
+// Track registers a zero-copy send submitted with userData and buffer buf.
+// buf may be nil for IORING_OP_SENDMSG_ZC, whose buffers the caller keeps
+// alive itself. It reports false if userData is already in flight.
+func (t *ZCTracker) Track(userData uint64, buf []byte) bool {
+	if _, ok := t.inflight[userData]; ok {
+		return false
+	}
+	var s *zcSend
+	if n := len(t.free); n > 0 {
+		s, t.free = t.free[n-1], t.free[:n-1]
+	} else {
+		s = new(zcSend)
+	}
+	s.buf = buf
+	t.inflight[userData] = s
+	return true
+}
+
+// Handle consumes cqe if it belongs to a tracked send and reports whether
+// it did. The release function runs from Handle once both completions have
+// been seen, or after the result completion alone when a missing
+// IORING_CQE_F_MORE signals that no notification will follow.
+func (t *ZCTracker) Handle(cqe *IoUringCqe) bool {
+	s, ok := t.inflight[cqe.UserData]
+	if !ok {
+		return false
+	}
+	if cqe.Flags&IORING_CQE_F_NOTIF != 0 {
+		s.result.Copied = uint32(cqe.Res)&IORING_NOTIF_USAGE_ZC_COPIED != 0
+		s.notified = true
+		if s.done {
+			t.finish(cqe.UserData, s)
+		}
+		return true
+	}
+	if s.done {
+		return false
+	}
+	s.done = true
+	if cqe.Res < 0 {
+		s.result.Errno = uintptr(-cqe.Res)
+	} else {
+		s.result.N = cqe.Res
+	}
+	if s.notified || cqe.Flags&IORING_CQE_F_MORE == 0 {
+		t.finish(cqe.UserData, s)
+	}
+	return true
+}
+
+// Pending returns the number of sends whose buffer has not been released.
+func (t *ZCTracker) Pending() int {
+	return len(t.inflight)
+}
+
+func (t *ZCTracker) finish(userData uint64, s *zcSend) {
+	delete(t.inflight, userData)
+	buf, r := s.buf, s.result
+	*s = zcSend{}
+	t.free = append(t.free, s)
+	t.release(userData, buf, r)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package zcall_test
+
+import (
+	"testing"
+
+	"code.hybscloud.com/zcall"
+)
+
+type zcRelease struct {
+	userData uint64
+	buf      []byte
+	res      zcall.ZCResult
+}
+
+func newRecordingTracker() (*zcall.ZCTracker, *[]zcRelease) {
+	var released []zcRelease
+	tr := zcall.NewZCTracker(func(userData uint64, buf []byte, r zcall.ZCResult) {
+		released = append(released, zcRelease{userData, buf, r})
+	})
+	return tr, &released
+}
+
+func TestZCTrackerPairing(t *testing.T) {
+	tr, released := newRecordingTracker()
+	buf := []byte("payload")
+	if !tr.Track(1, buf) {
+		t.Fatal("Track failed")
+	}
+	if tr.Track(1, buf) {
+		t.Fatal("Track accepted a duplicate user data")
+	}
+
+	if tr.Handle(&zcall.IoUringCqe{UserData: 2, Res: 3}) {
+		t.Fatal("Handle consumed an untracked completion")
+	}
+	if !tr.Handle(&zcall.IoUringCqe{UserData: 1, Res: 7, Flags: zcall.IORING_CQE_F_MORE}) {
+		t.Fatal("Handle ignored the result completion")
+	}
+	if len(*released) != 0 || tr.Pending() != 1 {
+		t.Fatal("buffer released before the notification")
+	}
+	notif := zcall.IoUringCqe{UserData: 1, Res: -1 << 31, Flags: zcall.IORING_CQE_F_NOTIF}
+	if !tr.Handle(&notif) {
+		t.Fatal("Handle ignored the notification")
+	}
+	if tr.Pending() != 0 || len(*released) != 1 {
+		t.Fatalf("Pending = %d, released = %d; want 0, 1", tr.Pending(), len(*released))
+	}
+	got := (*released)[0]
+	if got.userData != 1 || &got.buf[0] != &buf[0] || got.res != (zcall.ZCResult{N: 7, Copied: true}) {
+		t.Fatalf("release = %+v", got)
+	}
+
+	// The user data can be reused once released.
+	if !tr.Track(1, buf) {
+		t.Fatal("Track after release failed")
+	}
+}
+
+func TestZCTrackerNoNotification(t *testing.T) {
+	tr, released := newRecordingTracker()
+	tr.Track(4, nil)
+	// A failed send without IORING_CQE_F_MORE posts no notification.
+	tr.Handle(&zcall.IoUringCqe{UserData: 4, Res: -int32(zcall.EPIPE)})
+	if len(*released) != 1 || (*released)[0].res != (zcall.ZCResult{Errno: uintptr(zcall.EPIPE)}) {
+		t.Fatalf("released = %+v", *released)
+	}
+}
+
+func TestZCTrackerNotificationFirst(t *testing.T) {
+	tr, released := newRecordingTracker()
+	tr.Track(5, nil)
+	tr.Handle(&zcall.IoUringCqe{UserData: 5, Flags: zcall.IORING_CQE_F_NOTIF})
+	if len(*released) != 0 {
+		t.Fatal("released before the result completion")
+	}
+	tr.Handle(&zcall.IoUringCqe{UserData: 5, Res: 2, Flags: zcall.IORING_CQE_F_MORE})
+	if len(*released) != 1 || (*released)[0].res.N != 2 {
+		t.Fatalf("released = %+v", *released)
+	}
+}
+
+func TestZCTrackerSendZC(t *testing.T) {
+	r := newTestRing(t, 8, 0)
+	a, b := testTCPPair(t)
+	tr, released := newRecordingTracker()
+
+	msg := []byte("tracked zero-copy")
+	r.push(1, func(sqe *zcall.IoUringSqe) {
+		zcall.PrepSendZC(sqe, a, msg, 0, zcall.IORING_SEND_ZC_REPORT_USAGE)
+	})
+	tr.Track(1, msg)
+	r.enter(2)
+	for {
+		cqe, ok := r.pop()
+		if !ok {
+			break
+		}
+		skipIfUnsupported(t, "SEND_ZC", cqe)
+		if !tr.Handle(&cqe) {
+			t.Fatalf("untracked completion %+v", cqe)
+		}
+	}
+	if len(*released) != 1 {
+		t.Fatalf("released = %d sends, want 1", len(*released))
+	}
+	res := (*released)[0].res
+	if res.Errno != 0 || res.N != int32(len(msg)) {
+		t.Fatalf("result = %+v", res)
+	}
+	// Loopback delivery cannot keep user pages, so the kernel copies.
+	if !res.Copied {
+		t.Fatal("loopback zero-copy send not reported as copied")
+	}
+
+	buf := make([]byte, 32)
+	if n, _ := zcall.Read(uintptr(b), buf); string(buf[:n]) != string(msg) {
+		t.Fatalf("peer read %q", buf[:n])
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+// Package uring provides a completion-dispatching event loop on top of the
+// io_uring wrappers of package zcall.
+//
+// # Overview
+//
+// A Loop owns an io_uring instance created with IORING_SETUP_SINGLE_ISSUER
+// and IORING_SETUP_DEFER_TASKRUN, a table of in-flight requests, and the
+// Handler of each request. Every request is tagged with a Token that
+// combines a table index with a generation, so a token held after its
+// request completed never names a later request that reuses the slot.
+//
+//	sqe, tok := loop.Next(h)
+//	zcall.PrepRead(sqe, fd, buf, 0)
+//	n, errno := loop.Wait(1) // h.Handle(tok, res, flags)
+//
+// # Single Issuer
+//
+// The kernel binds an IORING_SETUP_SINGLE_ISSUER ring to the thread that
+// created it and fails submissions from any other thread with EEXIST. A
+// Loop is therefore not safe for concurrent use: create it and call its
+// methods from one goroutine locked to its thread with
+// runtime.LockOSThread. Completions are only posted while that goroutine
+// waits, which keeps handlers on the same thread as the submissions.
+//
+// # Allocation
+//
+// Once the token table has grown to the peak number of in-flight requests,
+// submitting, waiting, and dispatching do not allocate. The arguments of
+// the io_uring_enter system call live in the Loop for that reason.
+package uring
@@ -0,0 +1,283 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package uring
+
+import (
+	"time"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+)
+
+// Token identifies a request submitted through a Loop. The low 32 bits
+// index the token table and the high 32 bits hold the generation of the
+// slot. A slot changes generation whenever it is released, so the zero
+// Token and the tokens of finished requests match no request.
+type Token uint64
+
+func makeToken(index, gen uint32) Token {
+	return Token(uint64(gen)<<32 | uint64(index))
+}
+
+func (t Token) index() uint32 { return uint32(t) }
+func (t Token) gen() uint32   { return uint32(t >> 32) }
+
+// Handler receives the completions of the requests it was registered for.
+// res and flags are the Res and Flags of the completion queue entry. A
+// request stays in flight while flags include IORING_CQE_F_MORE; after the
+// last completion its token is released and may be handed out again, even
+// before Handle returns. Handle may submit further requests on the Loop.
+type Handler interface {
+	Handle(tok Token, res int32, flags uint32)
+}
+
+// slot is an entry of the token table. h is nil while the slot is free.
+type slot struct {
+	h   Handler
+	gen uint32
+}
+
+// Loop is a single-issuer io_uring event loop. See the package
+// documentation for the threading rules.
+type Loop struct {
+	fd    uintptr
+	rings zcall.IoUringRings
+	sq    *zcall.SubmissionQueue
+	cq    *zcall.CompletionQueue
+
+	slots []slot
+	free  []uint32
+
+	// last is the entry handed out by Next, stamped with lastTok once the
+	// caller has prepared it.
+	last    *zcall.IoUringSqe
+	lastTok Token
+
+	// System call arguments are kept here so that passing their addresses
+	// to the kernel does not move them to the heap on every call.
+	params zcall.IoUringParams
+	arg    zcall.IoUringGeteventsArg
+	ts     zcall.Timespec
+}
+
+// NewLoop creates an io_uring instance with room for entries submission
+// queue entries and maps its rings. flags are extra IORING_SETUP_* flags;
+// IORING_SETUP_SINGLE_ISSUER and IORING_SETUP_DEFER_TASKRUN are always set.
+// The calling thread becomes the only thread allowed to submit.
+func NewLoop(entries uint32, flags uint32) (l *Loop, errno uintptr) {
+	l = &Loop{}
+	l.params.Flags = flags | zcall.IORING_SETUP_SINGLE_ISSUER | zcall.IORING_SETUP_DEFER_TASKRUN
+	l.fd, errno = zcall.IoUringSetup(uintptr(entries), unsafe.Pointer(&l.params))
+	if errno != 0 {
+		return nil, errno
+	}
+	l.rings, errno = zcall.IoUringMapRings(l.fd, &l.params)
+	if errno != 0 {
+		zcall.Close(l.fd)
+		return nil, errno
+	}
+	l.sq = zcall.NewSubmissionQueue(&l.rings)
+	l.cq = zcall.NewCompletionQueue(&l.rings)
+	n := l.cq.Entries()
+	l.slots = make([]slot, 0, n)
+	l.free = make([]uint32, 0, n)
+	return l, 0
+}
+
+// Fd returns the io_uring instance file descriptor, for registering
+// resources with zcall.IoUringRegister and its helpers.
+func (l *Loop) Fd() uintptr {
+	return l.fd
+}
+
+// Params returns the setup parameters filled in by the kernel.
+func (l *Loop) Params() *zcall.IoUringParams {
+	return &l.params
+}
+
+// Next returns a submission queue entry for a request whose completions
+// are dispatched to h, and the token of the request. The caller prepares
+// the entry with a Prep function before the next call on the Loop; the
+// Loop sets UserData itself. When the submission queue is full, Next
+// submits the pending entries first. It returns nil when h is nil or no
+// entry is available.
+func (l *Loop) Next(h Handler) (sqe *zcall.IoUringSqe, tok Token) {
+	if h == nil {
+		return nil, 0
+	}
+	l.stamp()
+	sqe = l.sq.NextSQE()
+	if sqe == nil {
+		if _, errno := l.sq.Submit(l.fd); errno != 0 {
+			return nil, 0
+		}
+		if sqe = l.sq.NextSQE(); sqe == nil {
+			return nil, 0
+		}
+	}
+	tok = l.alloc(h)
+	l.last, l.lastTok = sqe, tok
+	return sqe, tok
+}
+
+// Cancel requests the cancellation of the request tok. The request
+// completes with -ECANCELED unless it finishes first. Cancel reports
+// false if tok is not in flight or no submission queue entry is available.
+func (l *Loop) Cancel(tok Token) bool {
+	if !l.Valid(tok) {
+		return false
+	}
+	l.stamp()
+	sqe := l.sq.NextSQE()
+	if sqe == nil {
+		if _, errno := l.sq.Submit(l.fd); errno != 0 {
+			return false
+		}
+		if sqe = l.sq.NextSQE(); sqe == nil {
+			return false
+		}
+	}
+	// The completion of the cancel request carries the zero Token and is
+	// not dispatched.
+	zcall.PrepCancel(sqe, uint64(tok), 0)
+	return true
+}
+
+// Valid reports whether tok names a request that is still in flight.
+func (l *Loop) Valid(tok Token) bool {
+	i := tok.index()
+	return i < uint32(len(l.slots)) && l.slots[i].h != nil && l.slots[i].gen == tok.gen()
+}
+
+// Pending returns the number of requests in flight.
+func (l *Loop) Pending() int {
+	return len(l.slots) - len(l.free)
+}
+
+// Submit submits the prepared entries without waiting. It returns the
+// number of entries submitted.
+func (l *Loop) Submit() (n int, errno uintptr) {
+	l.stamp()
+	r1, errno := l.sq.Submit(l.fd)
+	return int(r1), errno
+}
+
+// Poll submits the prepared entries, runs the deferred task work of the
+// ring without waiting, and dispatches the available completions. It
+// returns the number of completions dispatched.
+func (l *Loop) Poll() (n int, errno uintptr) {
+	return l.enter(0, 0, nil, 0)
+}
+
+// Wait submits the prepared entries, waits until at least minComplete
+// completions are available, and dispatches them. It returns the number of
+// completions dispatched. A wait interrupted by a signal returns EINTR
+// after dispatching what is available.
+func (l *Loop) Wait(minComplete uint32) (n int, errno uintptr) {
+	return l.enter(minComplete, 0, nil, 0)
+}
+
+// WaitTimeout is Wait bounded by timeout. When the timeout expires before
+// minComplete completions are available, errno is ETIME and the available
+// completions are still dispatched. Unlike Wait, a wait interrupted by a
+// signal is resumed for the rest of the timeout, so WaitTimeout does not
+// return EINTR.
+func (l *Loop) WaitTimeout(minComplete uint32, timeout time.Duration) (n int, errno uintptr) {
+	deadline := time.Now().Add(timeout)
+	for {
+		l.ts = zcall.Timespec{Sec: int64(timeout / time.Second), Nsec: int64(timeout % time.Second)}
+		l.arg = zcall.IoUringGeteventsArg{Ts: uint64(uintptr(unsafe.Pointer(&l.ts)))}
+		d, errno := l.enter(minComplete, zcall.IORING_ENTER_EXT_ARG, unsafe.Pointer(&l.arg), unsafe.Sizeof(l.arg))
+		n += d
+		if errno != uintptr(zcall.EINTR) {
+			return n, errno
+		}
+		if uint32(d) >= minComplete {
+			return n, 0
+		}
+		minComplete -= uint32(d)
+		if timeout = time.Until(deadline); timeout <= 0 {
+			return n, uintptr(zcall.ETIME)
+		}
+	}
+}
+
+// Close unmaps the rings and closes the io_uring instance. Requests still
+// in flight are cancelled by the kernel without being dispatched. The
+// instance is closed even if unmapping fails; errno is the first error.
+func (l *Loop) Close() (errno uintptr) {
+	l.last = nil
+	errno = l.rings.Unmap()
+	if e := zcall.Close(l.fd); errno == 0 {
+		errno = e
+	}
+	return errno
+}
+
+// enter flushes the prepared entries into io_uring_enter with
+// IORING_ENTER_GETEVENTS, which a DEFER_TASKRUN ring needs to post
+// completions, and dispatches the completion queue.
+func (l *Loop) enter(minComplete uint32, flags uintptr, arg unsafe.Pointer, argSize uintptr) (n int, errno uintptr) {
+	l.stamp()
+	toSubmit := l.sq.Flush()
+	_, errno = zcall.IoUringEnter(l.fd, uintptr(toSubmit), uintptr(minComplete), flags|zcall.IORING_ENTER_GETEVENTS, arg, argSize)
+	return l.dispatch(), errno
+}
+
+// dispatch hands every available completion to the handler of its
+// request, releasing the token of requests that will not complete again.
+func (l *Loop) dispatch() (n int) {
+	for {
+		cqe := l.cq.PeekCQE()
+		if cqe == nil {
+			return n
+		}
+		tok, res, flags := Token(cqe.UserData), cqe.Res, cqe.Flags
+		l.cq.SeenCQE()
+		if !l.Valid(tok) {
+			continue
+		}
+		h := l.slots[tok.index()].h
+		if flags&zcall.IORING_CQE_F_MORE == 0 {
+			l.release(tok.index())
+		}
+		h.Handle(tok, res, flags)
+		n++
+	}
+}
+
+// stamp sets the UserData of the entry handed out by the last Next.
+func (l *Loop) stamp() {
+	if l.last != nil {
+		l.last.UserData = uint64(l.lastTok)
+		l.last = nil
+	}
+}
+
+// alloc takes a free slot for h, growing the table when none is free.
+func (l *Loop) alloc(h Handler) Token {
+	var i uint32
+	if n := len(l.free); n > 0 {
+		i = l.free[n-1]
+		l.free = l.free[:n-1]
+	} else {
+		i = uint32(len(l.slots))
+		l.slots = append(l.slots, slot{gen: 1})
+	}
+	l.slots[i].h = h
+	return makeToken(i, l.slots[i].gen)
+}
+
+// release frees slot i and advances its generation, skipping 0.
+func (l *Loop) release(i uint32) {
+	s := &l.slots[i]
+	s.h = nil
+	if s.gen++; s.gen == 0 {
+		s.gen = 1
+	}
+	l.free = append(l.free, i)
+}
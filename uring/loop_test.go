@@ -0,0 +1,344 @@
+// Copyright 2025 Hayabusa Cloud Co., Ltd. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build linux
+
+package uring_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+	"unsafe"
+
+	"code.hybscloud.com/zcall"
+	"code.hybscloud.com/zcall/uring"
+)
+
+// newTestLoop locks the test goroutine to its thread, which must issue
+// every call on the Loop, and creates a Loop closed at cleanup.
+func newTestLoop(t testing.TB, entries uint32) *uring.Loop {
+	t.Helper()
+	runtime.LockOSThread()
+	l, errno := uring.NewLoop(entries, 0)
+	if errno != 0 {
+		runtime.UnlockOSThread()
+		switch zcall.Errno(errno) {
+		case zcall.ENOSYS, zcall.EPERM:
+			t.Skip("io_uring not available")
+		case zcall.EINVAL:
+			t.Skip("IORING_SETUP_DEFER_TASKRUN not supported on this kernel")
+		}
+		t.Fatalf("NewLoop failed: %v", zcall.Errno(errno))
+	}
+	t.Cleanup(func() {
+		if errno := l.Close(); errno != 0 {
+			t.Errorf("Close failed: %v", zcall.Errno(errno))
+		}
+		runtime.UnlockOSThread()
+	})
+	return l
+}
+
+type completion struct {
+	tok   uring.Token
+	res   int32
+	flags uint32
+}
+
+// recorder records every completion it is handed.
+type recorder struct {
+	got []completion
+}
+
+func (r *recorder) Handle(tok uring.Token, res int32, flags uint32) {
+	r.got = append(r.got, completion{tok, res, flags})
+}
+
+// counter counts completions without allocating.
+type counter struct {
+	n   int
+	res int32
+}
+
+func (c *counter) Handle(tok uring.Token, res int32, flags uint32) {
+	c.n++
+	c.res = res
+}
+
+// wait waits for n completions.
+func wait(t testing.TB, l *uring.Loop, n int) {
+	t.Helper()
+	for got := 0; got < n; {
+		d, errno := l.WaitTimeout(1, time.Second)
+		got += d
+		if errno != 0 {
+			t.Fatalf("WaitTimeout failed after %d of %d completions: %v", got, n, zcall.Errno(errno))
+		}
+	}
+}
+
+func TestLoopDispatch(t *testing.T) {
+	l := newTestLoop(t, 8)
+	var r recorder
+	var toks [3]uring.Token
+	for i := range toks {
+		sqe, tok := l.Next(&r)
+		if sqe == nil {
+			t.Fatal("Next returned nil")
+		}
+		zcall.PrepNop(sqe)
+		toks[i] = tok
+	}
+	if got := l.Pending(); got != 3 {
+		t.Fatalf("Pending = %d, want 3", got)
+	}
+	wait(t, l, 3)
+
+	if len(r.got) != 3 {
+		t.Fatalf("got %d completions, want 3", len(r.got))
+	}
+	for i, c := range r.got {
+		if c.tok != toks[i] || c.res != 0 {
+			t.Errorf("completion %d = %+v, want token %#x res 0", i, c, toks[i])
+		}
+		if l.Valid(c.tok) {
+			t.Errorf("token %#x still valid after its completion", c.tok)
+		}
+	}
+	if got := l.Pending(); got != 0 {
+		t.Fatalf("Pending = %d, want 0", got)
+	}
+}
+
+func TestLoopTokenGeneration(t *testing.T) {
+	l := newTestLoop(t, 4)
+	var c counter
+	sqe, first := l.Next(&c)
+	zcall.PrepNop(sqe)
+	wait(t, l, 1)
+
+	sqe, second := l.Next(&c)
+	zcall.PrepNop(sqe)
+	if second == first {
+		t.Fatalf("reused slot kept token %#x", first)
+	}
+	if uint32(second) != uint32(first) {
+		t.Fatalf("token %#x does not reuse the slot of %#x", second, first)
+	}
+	if l.Valid(first) || !l.Valid(second) {
+		t.Fatal("Valid does not follow the generation")
+	}
+	if l.Cancel(first) {
+		t.Fatal("Cancel accepted a stale token")
+	}
+	if l.Valid(0) {
+		t.Fatal("zero token is valid")
+	}
+	wait(t, l, 1)
+	if c.n != 2 {
+		t.Fatalf("handled %d completions, want 2", c.n)
+	}
+}
+
+func TestLoopNilHandler(t *testing.T) {
+	l := newTestLoop(t, 4)
+	if sqe, tok := l.Next(nil); sqe != nil || tok != 0 {
+		t.Fatalf("Next(nil) = %p, %#x; want nil, 0", sqe, tok)
+	}
+	if got := l.Pending(); got != 0 {
+		t.Fatalf("Pending = %d, want 0", got)
+	}
+}
+
+func TestLoopRead(t *testing.T) {
+	l := newTestLoop(t, 4)
+	var fds [2]int32
+	if errno := zcall.Pipe2(&fds, zcall.O_CLOEXEC); errno != 0 {
+		t.Fatalf("Pipe2 failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Close(uintptr(fds[0]))
+	defer zcall.Close(uintptr(fds[1]))
+
+	buf := make([]byte, 16)
+	var c counter
+	sqe, tok := l.Next(&c)
+	zcall.PrepRead(sqe, fds[0], buf, 0)
+	if _, errno := l.Poll(); errno != 0 {
+		t.Fatalf("Poll failed: %v", zcall.Errno(errno))
+	}
+	if c.n != 0 || !l.Valid(tok) {
+		t.Fatal("read completed before data was written")
+	}
+
+	if _, errno := zcall.Write(uintptr(fds[1]), []byte("hello")); errno != 0 {
+		t.Fatalf("Write failed: %v", zcall.Errno(errno))
+	}
+	wait(t, l, 1)
+	if c.res != 5 || string(buf[:5]) != "hello" {
+		t.Fatalf("read res = %d, data %q", c.res, buf[:5])
+	}
+}
+
+func TestLoopMultishotAndCancel(t *testing.T) {
+	l := newTestLoop(t, 4)
+	efd, errno := zcall.Eventfd2(0, zcall.EFD_NONBLOCK|zcall.EFD_CLOEXEC)
+	if errno != 0 {
+		t.Fatalf("Eventfd2 failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Close(efd)
+
+	var r recorder
+	sqe, tok := l.Next(&r)
+	zcall.PrepPollAdd(sqe, int32(efd), zcall.POLLIN)
+	sqe.Len = zcall.IORING_POLL_ADD_MULTI
+	if _, errno := l.Submit(); errno != 0 {
+		t.Fatalf("Submit failed: %v", zcall.Errno(errno))
+	}
+
+	val := uint64(1)
+	buf := (*[8]byte)(unsafe.Pointer(&val))[:]
+	for i := 0; i < 2; i++ {
+		zcall.Write(efd, buf)
+		wait(t, l, 1)
+		zcall.Read(efd, buf)
+	}
+	if len(r.got) != 2 {
+		t.Fatalf("got %d completions, want 2", len(r.got))
+	}
+	for _, c := range r.got {
+		if c.tok != tok || c.flags&zcall.IORING_CQE_F_MORE == 0 {
+			t.Fatalf("completion %+v, want token %#x with IORING_CQE_F_MORE", c, tok)
+		}
+	}
+	if !l.Valid(tok) || l.Pending() != 1 {
+		t.Fatal("multishot request released before its last completion")
+	}
+
+	if !l.Cancel(tok) {
+		t.Fatal("Cancel failed")
+	}
+	wait(t, l, 1)
+	last := r.got[len(r.got)-1]
+	if last.res != -int32(zcall.ECANCELED) || last.flags&zcall.IORING_CQE_F_MORE != 0 {
+		t.Fatalf("final completion %+v, want -ECANCELED without IORING_CQE_F_MORE", last)
+	}
+	if l.Valid(tok) || l.Pending() != 0 {
+		t.Fatal("cancelled request still in flight")
+	}
+}
+
+// chain resubmits a no-op from its handler until n completions were handled.
+type chain struct {
+	l *uring.Loop
+	n int
+}
+
+func (c *chain) Handle(tok uring.Token, res int32, flags uint32) {
+	c.n--
+	if c.n > 0 {
+		sqe, _ := c.l.Next(c)
+		zcall.PrepNop(sqe)
+	}
+}
+
+func TestLoopSubmitFromHandler(t *testing.T) {
+	l := newTestLoop(t, 2)
+	c := &chain{l: l, n: 5}
+	sqe, _ := l.Next(c)
+	zcall.PrepNop(sqe)
+	wait(t, l, 5)
+	if c.n != 0 || l.Pending() != 0 {
+		t.Fatalf("remaining = %d, pending = %d", c.n, l.Pending())
+	}
+}
+
+func TestLoopWaitTimeout(t *testing.T) {
+	l := newTestLoop(t, 2)
+	start := time.Now()
+	n, errno := l.WaitTimeout(1, 10*time.Millisecond)
+	if n != 0 || zcall.Errno(errno) != zcall.ETIME {
+		t.Fatalf("WaitTimeout = %d, %v; want 0, ETIME", n, zcall.Errno(errno))
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Fatalf("WaitTimeout returned after %v", elapsed)
+	}
+}
+
+func TestLoopGrowsTokenTable(t *testing.T) {
+	// More requests than completion queue entries stay distinguishable.
+	l := newTestLoop(t, 1)
+	entries := l.Params().CqEntries
+	var r recorder
+	seen := make(map[uring.Token]bool)
+	for i := uint32(0); i < 2*entries; i++ {
+		sqe, tok := l.Next(&r)
+		if sqe == nil {
+			t.Fatal("Next returned nil")
+		}
+		zcall.PrepNop(sqe)
+		if seen[tok] {
+			t.Fatalf("token %#x handed out twice", tok)
+		}
+		seen[tok] = true
+	}
+	wait(t, l, int(2*entries))
+	for _, c := range r.got {
+		if !seen[c.tok] {
+			t.Fatalf("completion for unknown token %#x", c.tok)
+		}
+	}
+}
+
+func TestLoopSteadyStateAllocs(t *testing.T) {
+	l := newTestLoop(t, 4)
+	var c counter
+	round := func() {
+		sqe, _ := l.Next(&c)
+		zcall.PrepNop(sqe)
+		l.Wait(1)
+		l.WaitTimeout(0, time.Millisecond)
+	}
+	round()
+	if allocs := testing.AllocsPerRun(100, round); allocs != 0 {
+		t.Fatalf("steady state allocates %v times per round", allocs)
+	}
+}
+
+func BenchmarkLoopNop(b *testing.B) {
+	l := newTestLoop(b, 64)
+	var c counter
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sqe, _ := l.Next(&c)
+		zcall.PrepNop(sqe)
+		l.Wait(1)
+	}
+}
+
+// BenchmarkLoopEventfd does the eventfd write and read of
+// zcall's BenchmarkSyscall4 through the Loop.
+func BenchmarkLoopEventfd(b *testing.B) {
+	l := newTestLoop(b, 64)
+	fd, errno := zcall.Eventfd2(0, zcall.EFD_NONBLOCK|zcall.EFD_CLOEXEC)
+	if errno != 0 {
+		b.Fatalf("Eventfd2 failed: %v", zcall.Errno(errno))
+	}
+	defer zcall.Close(fd)
+
+	val := uint64(1)
+	buf := (*[8]byte)(unsafe.Pointer(&val))[:]
+	var c counter
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sqe, _ := l.Next(&c)
+		zcall.PrepWrite(sqe, int32(fd), buf, 0)
+		sqe.Flags |= zcall.IOSQE_IO_LINK
+		sqe, _ = l.Next(&c)
+		zcall.PrepRead(sqe, int32(fd), buf, 0)
+		l.Wait(2)
+	}
+}
@@ -284,3 +284,55 @@ func Munmap(addr unsafe.Pointer, length uintptr) (errno uintptr) {
 	_, errno = Syscall4(SYS_MUNMAP, uintptr(addr), length, 0, 0)
 	return
 }
+
+// Futex performs the futex operation op on the futex word at uaddr.
+// timeout is a *Timespec, or nil; it is relative for FUTEX_WAIT and
+// absolute for FUTEX_WAIT_BITSET and the PI operations. Operations that
+// take a val2 count in place of the timeout use FutexVal2.
+func Futex(uaddr unsafe.Pointer, op, val uintptr, timeout, uaddr2 unsafe.Pointer, val3 uintptr) (r1 uintptr, errno uintptr) {
+	return Syscall6(SYS_FUTEX, uintptr(noescape(uaddr)), op, val, uintptr(noescape(timeout)), uintptr(noescape(uaddr2)), val3)
+}
+
+// FutexVal2 performs a futex operation that takes a val2 count in the
+// timeout argument, such as FUTEX_REQUEUE, FUTEX_CMP_REQUEUE, and
+// FUTEX_WAKE_OP.
+func FutexVal2(uaddr unsafe.Pointer, op, val, val2 uintptr, uaddr2 unsafe.Pointer, val3 uintptr) (r1 uintptr, errno uintptr) {
+	return Syscall6(SYS_FUTEX, uintptr(noescape(uaddr)), op, val, val2, uintptr(noescape(uaddr2)), val3)
+}
+
+// FutexWaitv waits on up to FUTEX_WAITV_MAX futexes described by an array of
+// nr FutexWaiter entries, until one is woken. timeout is an absolute
+// *Timespec on clockid, or nil. flags must be 0. It returns the index of the
+// woken futex.
+func FutexWaitv(waiters unsafe.Pointer, nr, flags uintptr, timeout unsafe.Pointer, clockid uintptr) (index uintptr, errno uintptr) {
+	return Syscall6(SYS_FUTEX_WAITV, uintptr(noescape(waiters)), nr, flags, uintptr(noescape(timeout)), clockid, 0)
+}
+
+// FutexWake wakes up to nr waiters on the futex at uaddr whose wait mask
+// intersects mask. flags are FUTEX2_* flags. It returns the number of
+// waiters woken.
+func FutexWake(uaddr unsafe.Pointer, mask, nr, flags uintptr) (woken uintptr, errno uintptr) {
+	return Syscall4(SYS_FUTEX_WAKE, uintptr(noescape(uaddr)), mask, nr, flags)
+}
+
+// FutexWait waits on the futex at uaddr while it holds val, until woken by
+// a wake whose mask intersects mask. flags are FUTEX2_* flags. timeout is an
+// absolute *Timespec on clockid, or nil.
+func FutexWait(uaddr unsafe.Pointer, val, mask, flags uintptr, timeout unsafe.Pointer, clockid uintptr) (errno uintptr) {
+	_, errno = Syscall6(SYS_FUTEX_WAIT, uintptr(noescape(uaddr)), val, mask, flags, uintptr(noescape(timeout)), clockid)
+	return
+}
+
+// SchedSetaffinity sets the CPU affinity mask of the thread pid, or of the
+// calling thread if pid is 0, to the size-byte mask, usually a *CPUSet.
+func SchedSetaffinity(pid, size uintptr, mask unsafe.Pointer) (errno uintptr) {
+	_, errno = Syscall4(SYS_SCHED_SETAFFINITY, pid, size, uintptr(noescape(mask)), 0)
+	return
+}
+
+// SchedGetaffinity reads the CPU affinity mask of the thread pid, or of the
+// calling thread if pid is 0, into the size-byte mask, usually a *CPUSet.
+// It returns the number of bytes written.
+func SchedGetaffinity(pid, size uintptr, mask unsafe.Pointer) (n uintptr, errno uintptr) {
+	return Syscall4(SYS_SCHED_GETAFFINITY, pid, size, uintptr(noescape(mask)), 0)
+}
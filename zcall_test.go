@@ -8,6 +8,8 @@ package zcall_test
 
 import (
 	"errors"
+	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 	"unsafe"
@@ -823,21 +825,7 @@ func TestSpliceWithOffsets(t *testing.T) {
 }
 
 func TestIoUringSetup(t *testing.T) {
-	// io_uring_setup requires a params struct
-	type ioUringParams struct {
-		sqEntries    uint32
-		cqEntries    uint32
-		flags        uint32
-		sqThreadCpu  uint32
-		sqThreadIdle uint32
-		features     uint32
-		wqFd         uint32
-		resv         [3]uint32
-		sqOff        [10]uint32
-		cqOff        [10]uint32
-	}
-
-	var params ioUringParams
+	var params zcall.IoUringParams
 	fd, errno := zcall.IoUringSetup(8, unsafe.Pointer(&params))
 	if errno != 0 {
 		// io_uring may not be available on all systems
@@ -1409,3 +1397,184 @@ func BenchmarkSignalfd4(b *testing.B) {
 		zcall.Close(fd)
 	}
 }
+
+func TestFutex(t *testing.T) {
+	word := new(uint32)
+	addr := unsafe.Pointer(word)
+	const op = zcall.FUTEX_PRIVATE_FLAG
+
+	// A wait on a word that no longer holds val returns at once.
+	if _, errno := zcall.Futex(addr, op|zcall.FUTEX_WAIT, 1, nil, nil, 0); zcall.Errno(errno) != zcall.EAGAIN {
+		t.Fatalf("FUTEX_WAIT mismatch errno = %v, want EAGAIN", zcall.Errno(errno))
+	}
+	ts := zcall.Timespec{Nsec: int64(time.Millisecond)}
+	for {
+		_, errno := zcall.Futex(addr, op|zcall.FUTEX_WAIT, 0, unsafe.Pointer(&ts), nil, 0)
+		if zcall.Errno(errno) == zcall.EINTR {
+			continue
+		}
+		if zcall.Errno(errno) != zcall.ETIMEDOUT {
+			t.Fatalf("FUTEX_WAIT timeout errno = %v, want ETIMEDOUT", zcall.Errno(errno))
+		}
+		break
+	}
+	if n, errno := zcall.Futex(addr, op|zcall.FUTEX_WAKE, 1, nil, nil, 0); errno != 0 || n != 0 {
+		t.Fatalf("FUTEX_WAKE = %d, %v; want 0 waiters", n, zcall.Errno(errno))
+	}
+
+	// FUTEX_CMP_REQUEUE checks val3 against the word before requeueing.
+	other := new(uint32)
+	_, errno := zcall.FutexVal2(addr, op|zcall.FUTEX_CMP_REQUEUE, 1, 1, unsafe.Pointer(other), 5)
+	if zcall.Errno(errno) != zcall.EAGAIN {
+		t.Fatalf("FUTEX_CMP_REQUEUE errno = %v, want EAGAIN", zcall.Errno(errno))
+	}
+}
+
+// futexWaitUntilSet calls wait until *word is no longer 0. The raw system
+// calls do not release the P, so each wait must be bounded by a short
+// timeout to keep the runtime from stalling on the blocked goroutine.
+func futexWaitUntilSet(word *uint32, wait func() uintptr) uintptr {
+	for atomic.LoadUint32(word) == 0 {
+		switch errno := wait(); zcall.Errno(errno) {
+		case 0, zcall.EINTR, zcall.EAGAIN, zcall.ETIMEDOUT:
+		default:
+			return errno
+		}
+	}
+	return 0
+}
+
+func TestFutexWakeWaiter(t *testing.T) {
+	word := new(uint32)
+	done := make(chan uintptr)
+	go func() {
+		done <- futexWaitUntilSet(word, func() uintptr {
+			ts := zcall.Timespec{Nsec: int64(time.Millisecond)}
+			_, errno := zcall.Futex(unsafe.Pointer(word), zcall.FUTEX_WAIT|zcall.FUTEX_PRIVATE_FLAG, 0, unsafe.Pointer(&ts), nil, 0)
+			return errno
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	atomic.StoreUint32(word, 1)
+	zcall.Futex(unsafe.Pointer(word), zcall.FUTEX_WAKE|zcall.FUTEX_PRIVATE_FLAG, 1, nil, nil, 0)
+	select {
+	case errno := <-done:
+		if errno != 0 {
+			t.Fatalf("FUTEX_WAIT failed: %v", zcall.Errno(errno))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter not woken")
+	}
+}
+
+func TestFutex2(t *testing.T) {
+	word := new(uint32)
+	addr := unsafe.Pointer(word)
+	const flags = zcall.FUTEX2_SIZE_U32 | zcall.FUTEX2_PRIVATE
+
+	n, errno := zcall.FutexWake(addr, zcall.FUTEX_BITSET_MATCH_ANY, 1, flags)
+	if zcall.Errno(errno) == zcall.ENOSYS {
+		t.Skip("futex_wake not supported on this kernel")
+	}
+	if errno != 0 || n != 0 {
+		t.Fatalf("FutexWake = %d, %v; want 0 waiters", n, zcall.Errno(errno))
+	}
+	if errno := zcall.FutexWait(addr, 1, zcall.FUTEX_BITSET_MATCH_ANY, flags, nil, 0); zcall.Errno(errno) != zcall.EAGAIN {
+		t.Fatalf("FutexWait mismatch errno = %v, want EAGAIN", zcall.Errno(errno))
+	}
+	// An absolute time of zero has already passed.
+	var ts zcall.Timespec
+	errno = zcall.FutexWait(addr, 0, zcall.FUTEX_BITSET_MATCH_ANY, flags, unsafe.Pointer(&ts), zcall.CLOCK_MONOTONIC)
+	if zcall.Errno(errno) != zcall.ETIMEDOUT {
+		t.Fatalf("FutexWait timeout errno = %v, want ETIMEDOUT", zcall.Errno(errno))
+	}
+	// Sizes other than 32 bits are not implemented.
+	if _, errno := zcall.FutexWake(addr, zcall.FUTEX_BITSET_MATCH_ANY, 1, zcall.FUTEX2_SIZE_U64); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("FutexWake FUTEX2_SIZE_U64 errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+}
+
+func TestFutexWaitv(t *testing.T) {
+	if got := unsafe.Sizeof(zcall.FutexWaiter{}); got != 24 {
+		t.Fatalf("sizeof(FutexWaiter) = %d, want 24", got)
+	}
+	words := new([2]uint32)
+	waiters := []zcall.FutexWaiter{
+		{Uaddr: uint64(uintptr(unsafe.Pointer(&words[0]))), Flags: zcall.FUTEX_32 | zcall.FUTEX2_PRIVATE},
+		{Uaddr: uint64(uintptr(unsafe.Pointer(&words[1]))), Flags: zcall.FUTEX_32 | zcall.FUTEX2_PRIVATE},
+	}
+	var ts zcall.Timespec
+	_, errno := zcall.FutexWaitv(unsafe.Pointer(&waiters[0]), 2, 0, unsafe.Pointer(&ts), zcall.CLOCK_MONOTONIC)
+	if zcall.Errno(errno) == zcall.ENOSYS {
+		t.Skip("futex_waitv not supported on this kernel")
+	}
+	if zcall.Errno(errno) != zcall.ETIMEDOUT {
+		t.Fatalf("FutexWaitv timeout errno = %v, want ETIMEDOUT", zcall.Errno(errno))
+	}
+
+	done := make(chan uintptr, 1)
+	woken := ^uintptr(0)
+	go func() {
+		done <- futexWaitUntilSet(&words[1], func() uintptr {
+			deadline := time.Now().Add(time.Millisecond)
+			ts := zcall.Timespec{Sec: deadline.Unix(), Nsec: int64(deadline.Nanosecond())}
+			index, errno := zcall.FutexWaitv(unsafe.Pointer(&waiters[0]), 2, 0, unsafe.Pointer(&ts), zcall.CLOCK_REALTIME)
+			if errno == 0 {
+				woken = index
+			}
+			return errno
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	atomic.StoreUint32(&words[1], 1)
+	zcall.FutexWake(unsafe.Pointer(&words[1]), zcall.FUTEX_BITSET_MATCH_ANY, 1, zcall.FUTEX_32|zcall.FUTEX2_PRIVATE)
+	select {
+	case errno := <-done:
+		if errno != 0 {
+			t.Fatalf("FutexWaitv failed: %v", zcall.Errno(errno))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter not woken")
+	}
+	// A waiter that found the word already changed reports no index.
+	if woken != ^uintptr(0) && woken != 1 {
+		t.Fatalf("FutexWaitv woke index %d, want 1", woken)
+	}
+}
+
+func TestSchedAffinity(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var orig zcall.CPUSet
+	n, errno := zcall.SchedGetaffinity(0, unsafe.Sizeof(orig), unsafe.Pointer(&orig))
+	if errno != 0 {
+		t.Fatalf("SchedGetaffinity failed: %v", zcall.Errno(errno))
+	}
+	if n == 0 || n > unsafe.Sizeof(orig) || orig.Count() == 0 {
+		t.Fatalf("SchedGetaffinity = %d bytes, %d CPUs", n, orig.Count())
+	}
+	defer zcall.SchedSetaffinity(0, unsafe.Sizeof(orig), unsafe.Pointer(&orig))
+
+	// Pin the thread to the first CPU it may use.
+	var one zcall.CPUSet
+	for cpu := range zcall.CPUSetSize {
+		if orig.IsSet(cpu) {
+			one.Set(cpu)
+			break
+		}
+	}
+	if errno := zcall.SchedSetaffinity(0, unsafe.Sizeof(one), unsafe.Pointer(&one)); errno != 0 {
+		t.Fatalf("SchedSetaffinity failed: %v", zcall.Errno(errno))
+	}
+	var got zcall.CPUSet
+	zcall.SchedGetaffinity(0, unsafe.Sizeof(got), unsafe.Pointer(&got))
+	if got != one {
+		t.Fatalf("affinity = %v, want %v", got.Count(), one.Count())
+	}
+
+	var empty zcall.CPUSet
+	if errno := zcall.SchedSetaffinity(0, unsafe.Sizeof(empty), unsafe.Pointer(&empty)); zcall.Errno(errno) != zcall.EINVAL {
+		t.Fatalf("SchedSetaffinity(empty) errno = %v, want EINVAL", zcall.Errno(errno))
+	}
+}